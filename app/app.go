@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/health"
+	"github.com/etg-dev/restApi/logger"
+	"github.com/etg-dev/restApi/mailer"
+	"github.com/etg-dev/restApi/middleware"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/routes"
+	"github.com/etg-dev/restApi/schema"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// App owns the long lived dependencies of the service and hands them to the routes
+type App struct {
+	Config *configs.Config
+	Client *mongo.Client
+	Repos  *repositories.Repositories
+	Router *gin.Engine
+	Health *health.Checker
+
+	startHooks    []Hook
+	shutdownHooks []Hook
+}
+
+// New builds the router on top of the given repositories, no database is required
+func New(cfg *configs.Config, repos *repositories.Repositories) *App {
+	level, _ := logger.ParseLevel(cfg.Log.Level)
+	logger.SetLevel(level)
+	if level == logger.DebugLevel {
+		gin.SetMode(gin.DebugMode)
+	} else {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.Use(gin.Logger(), gin.CustomRecovery(middleware.Recover), middleware.ErrorHandler())
+	router.NoRoute(func(c *gin.Context) {
+		c.Error(apperrors.NotFound("Route not found"))
+	})
+	checker := health.NewChecker()
+
+	tokens := auth.NewTokenService(cfg.Auth, repos.Tokens)
+
+	routes.HealthRoute(router, checker)
+	routes.ProblemRoute(router)
+	routes.AuthRoute(router, repos, cfg, tokens, mailer.New(cfg.Mail))
+	routes.UserRoute(router, repos, cfg, tokens)
+	routes.PostRoute(router, repos, cfg, tokens)
+	routes.CommentRoute(router, repos, cfg, tokens)
+	routes.RoleRoute(router, repos, tokens)
+
+	return &App{Config: cfg, Repos: repos, Router: router, Health: checker}
+}
+
+// Build creates the application on the storage backend selected in the configuration
+func Build(ctx context.Context, cfg *configs.Config) (*App, error) {
+	var a *App
+	if cfg.Storage == configs.StorageMemory {
+		a = New(cfg, repositories.NewMemoryRepositories())
+	} else {
+		var err error
+		if a, err = NewMongo(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+	// registered last so the job stops before the database connection closes
+	a.schedulePurge()
+	return a, nil
+}
+
+// NewMongo connects to mongo and builds the application on mongo backed repositories
+func NewMongo(ctx context.Context, cfg *configs.Config) (*App, error) {
+	client, err := configs.ConnectDB(ctx, cfg.ConnectOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	db := configs.GetDatabase(client, cfg.Mongo.Database)
+	a := New(cfg, repositories.NewMongoRepositories(db))
+	a.Client = client
+	registerMongoChecks(a.Health, client, db)
+	a.OnStart("collections", func(ctx context.Context) error {
+		return ensureCollections(ctx, db)
+	})
+	a.OnStart("indexes", func(ctx context.Context) error {
+		return schema.EnsureIndexes(ctx, db)
+	})
+	a.OnShutdown("mongo", func(ctx context.Context) error {
+		return client.Disconnect(ctx)
+	})
+	return a, nil
+}
+
+// Server wraps the router in an http.Server using the configured address and timeouts
+func (a *App) Server() *http.Server {
+	return &http.Server{
+		Addr:         a.Config.Server.Addr,
+		Handler:      a.Router,
+		ReadTimeout:  time.Duration(a.Config.Server.ReadTimeout),
+		WriteTimeout: time.Duration(a.Config.Server.WriteTimeout),
+		IdleTimeout:  time.Duration(a.Config.Server.IdleTimeout),
+	}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		field  string
+	}{
+		{"valid", `{"email":"member@example.com","password":"secret-pass-1"}`, http.StatusOK, ""},
+		{"wrong password", `{"email":"member@example.com","password":"wrong-pass-1"}`, http.StatusUnauthorized, ""},
+		{"unknown email", `{"email":"nobody@example.com","password":"secret-pass-1"}`, http.StatusUnauthorized, ""},
+		{"user without a password", `{"email":"other@example.com","password":"secret-pass-1"}`, http.StatusUnauthorized, ""},
+		{"missing email", `{"password":"secret-pass-1"}`, http.StatusUnprocessableEntity, "email"},
+		{"invalid email", `{"email":"member","password":"secret-pass-1"}`, http.StatusUnprocessableEntity, "email"},
+		{"missing password", `{"email":"member@example.com"}`, http.StatusUnprocessableEntity, "password"},
+		{"malformed body", `{"email":`, http.StatusBadRequest, ""},
+	}
+
+	f := newFixture(t)
+	f.setPassword(t, "member", "secret-pass-1")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := f.do(http.MethodPost, "/api/auth/login", "anonymous", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.field != "" && !strings.Contains(rec.Body.String(), `"`+tt.field+`"`) {
+				t.Errorf("body %s has no error for %s", rec.Body, tt.field)
+			}
+		})
+	}
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	f := newFixture(t)
+	f.setPassword(t, "member", "secret-pass-1")
+	rec := f.do(http.MethodPost, "/api/auth/login", "anonymous", `{"email":"member@example.com","password":"secret-pass-1"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: status = %d, body %s", rec.Code, rec.Body)
+	}
+	first := tokenPair(t, rec)
+	f.bearer["member"] = first.AccessToken
+
+	steps := []struct {
+		name   string
+		path   string
+		as     string
+		body   string
+		status int
+	}{
+		{"refresh without a token", "/api/auth/refresh", "anonymous", `{}`, http.StatusUnprocessableEntity},
+		{"refresh with something else than a token", "/api/auth/refresh", "anonymous", `{"refreshToken":"nope"}`, http.StatusUnprocessableEntity},
+		{"refresh with an access token", "/api/auth/refresh", "anonymous", `{"refreshToken":"` + first.AccessToken + `"}`, http.StatusUnauthorized},
+		{"refresh", "/api/auth/refresh", "anonymous", `{"refreshToken":"` + first.RefreshToken + `"}`, http.StatusOK},
+		{"refresh tokens are single use", "/api/auth/refresh", "anonymous", `{"refreshToken":"` + first.RefreshToken + `"}`, http.StatusUnauthorized},
+		{"logout needs a token", "/api/auth/logout", "anonymous", "", http.StatusUnauthorized},
+		{"logout with something else than a token", "/api/auth/logout", "member", `{"refreshToken":"nope"}`, http.StatusUnprocessableEntity},
+		{"logout", "/api/auth/logout", "member", "", http.StatusOK},
+		{"the access token is revoked", "/api/auth/logout", "member", "", http.StatusUnauthorized},
+	}
+
+	for _, step := range steps {
+		rec := f.do(http.MethodPost, step.path, step.as, step.body)
+		if rec.Code != step.status {
+			t.Fatalf("%s: status = %d, want %d, body %s", step.name, rec.Code, step.status, rec.Body)
+		}
+	}
+}
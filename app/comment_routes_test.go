@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCommentRoutes(t *testing.T) {
+	comment := `{"body":"a reply"}`
+	commentPath := func(f *fixture) string { return "/api/comments/" + f.comment.Id.Hex() }
+
+	runRouteCases(t, []routeCase{
+		{"anonymous can not read comments", http.MethodGet, postPath("/comments/", false), "anonymous", "", http.StatusUnauthorized},
+		{"members read comments", http.MethodGet, postPath("/comments/", false), "other", "", http.StatusOK},
+		{"the depth is bounded", http.MethodGet, postPath("/comments/?depth=11", false), "other", "", http.StatusBadRequest},
+		{"unknown parents", http.MethodGet, postPath("/comments/?parent="+primitive.NewObjectID().Hex(), false), "other", "", http.StatusNotFound},
+		{"members comment", http.MethodPost, postPath("/comments/", false), "other", comment, http.StatusCreated},
+		{"deleted posts can not be commented", http.MethodPost, postPath("/comments/", true), "other", comment, http.StatusNotFound},
+		{"authors edit their comments", http.MethodPatch, commentPath, "member", comment, http.StatusOK},
+		{"members can not edit the comments of others", http.MethodPatch, commentPath, "other", comment, http.StatusForbidden},
+		{"members can not delete the comments of others", http.MethodDelete, commentPath, "other", "", http.StatusForbidden},
+		{"admins delete the comments of others", http.MethodDelete, commentPath, "admin", "", http.StatusOK},
+	})
+}
+
+func TestCommentThreads(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	// three more top level comments after the fixture's, each with a chain of two replies
+	var tops []*models.Comment
+	for i := 0; i < 3; i++ {
+		parent := &models.Comment{Post: f.post.Id, User: f.users["other"].Id, Body: fmt.Sprintf("top %d", i)}
+		if err := f.repos.Comments.Create(ctx, parent); err != nil {
+			t.Fatal(err)
+		}
+		tops = append(tops, parent)
+		for level := 1; level <= 2; level++ {
+			reply := &models.Comment{
+				Post:      f.post.Id,
+				User:      f.users["member"].Id,
+				Body:      fmt.Sprintf("reply %d.%d", i, level),
+				Parent:    &parent.Id,
+				Ancestors: append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.Id),
+			}
+			if err := f.repos.Comments.Create(ctx, reply); err != nil {
+				t.Fatal(err)
+			}
+			parent = reply
+		}
+	}
+
+	// thread is the part of a rendered comment thread the test looks at
+	type thread struct {
+		Body       string   `json:"body"`
+		ReplyCount int      `json:"replyCount"`
+		Replies    []thread `json:"replies"`
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		total int64
+		want  []thread
+	}{
+		{"first page", "?pageSize=2&depth=1", 4, []thread{{Body: "comment"}, {Body: "top 0", ReplyCount: 1}}},
+		{"second page", "?page=2&pageSize=2&depth=2", 4, []thread{
+			{Body: "top 1", ReplyCount: 1, Replies: []thread{{Body: "reply 1.1", ReplyCount: 1}}},
+			{Body: "top 2", ReplyCount: 1, Replies: []thread{{Body: "reply 2.1", ReplyCount: 1}}},
+		}},
+		{"below a comment", "?parent=" + tops[0].Id.Hex(), 1, []thread{
+			{Body: "reply 0.1", ReplyCount: 1, Replies: []thread{{Body: "reply 0.2", Replies: []thread{}}}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := f.do(http.MethodGet, "/api/posts/"+f.post.Id.Hex()+"/comments/"+tt.query, "other", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+
+			var body struct {
+				Data struct {
+					Data []thread `json:"data"`
+				} `json:"data"`
+				Meta struct {
+					Total int64 `json:"total"`
+				} `json:"meta"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Meta.Total != tt.total {
+				t.Errorf("total = %d, want %d", body.Meta.Total, tt.total)
+			}
+			if !reflect.DeepEqual(body.Data.Data, tt.want) {
+				t.Errorf("threads = %+v, want %+v", body.Data.Data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// fixture is an app on memory repositories with an admin, two members and some content
+type fixture struct {
+	app    *App
+	repos  *repositories.Repositories
+	tokens *auth.TokenService
+
+	users map[string]*models.User
+	// bearer holds an access token for every user, anonymous requests use ""
+	bearer map[string]string
+
+	post        *models.Post
+	deletedPost *models.Post
+	comment     *models.Comment
+	role        *models.Role
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	cfg := configs.Defaults(configs.EnvTest)
+	repos := repositories.NewMemoryRepositories()
+
+	f := &fixture{
+		app:    New(&cfg, repos),
+		repos:  repos,
+		tokens: auth.NewTokenService(cfg.Auth, repos.Tokens),
+		users:  map[string]*models.User{},
+		bearer: map[string]string{"anonymous": ""},
+	}
+
+	members := []string{permissions.Read, permissions.Create, permissions.Update, permissions.Delete}
+	f.addUser(t, "admin", append(members, permissions.Admin))
+	f.addUser(t, "member", members)
+	f.addUser(t, "other", members)
+
+	f.post = &models.Post{Title: "title", Content: "content", User: f.users["member"].Id}
+	f.deletedPost = &models.Post{Title: "deleted", Content: "content", User: f.users["member"].Id}
+	f.comment = &models.Comment{Body: "comment", User: f.users["member"].Id}
+	f.role = &models.Role{Name: "editors", Permissions: []string{permissions.Update}}
+	for _, post := range []*models.Post{f.post, f.deletedPost} {
+		if err := repos.Posts.Create(ctx, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.Posts.Delete(ctx, f.deletedPost.Id, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	f.comment.Post = f.post.Id
+	if err := repos.Comments.Create(ctx, f.comment); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Roles.Create(ctx, f.role); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (f *fixture) addUser(t *testing.T, name string, actions []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	user := &models.User{Name: name, Email: name + "@example.com"}
+	if err := f.repos.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.repos.Actions.Replace(ctx, user.Id, actions); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := f.tokens.Issue(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.users[name] = user
+	f.bearer[name] = pair.AccessToken
+}
+
+func (f *fixture) do(method, path, as, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token := f.bearer[as]; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	f.app.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+// routeCase is one request against a fresh fixture, path builds the url from its ids
+type routeCase struct {
+	name   string
+	method string
+	path   func(f *fixture) string
+	as     string
+	body   string
+	status int
+}
+
+func runRouteCases(t *testing.T, tests []routeCase) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			rec := f.do(tt.method, tt.path(f), tt.as, tt.body)
+			if rec.Code != tt.status {
+				t.Errorf("%s %s as %s: status = %d, want %d, body %s", tt.method, tt.path(f), tt.as, rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func path(p string) func(f *fixture) string {
+	return func(f *fixture) string { return p }
+}
+
+func userPath(user, suffix string) func(f *fixture) string {
+	return func(f *fixture) string { return "/api/users/" + f.users[user].Id.Hex() + suffix }
+}
+
+func postPath(suffix string, deleted bool) func(f *fixture) string {
+	return func(f *fixture) string {
+		if deleted {
+			return "/api/posts/" + f.deletedPost.Id.Hex() + suffix
+		}
+		return "/api/posts/" + f.post.Id.Hex() + suffix
+	}
+}
+
+// setPassword gives a fixture user a password to log in with
+func (f *fixture) setPassword(t *testing.T, name, password string) {
+	t.Helper()
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.repos.Users.SetPasswordHash(context.Background(), f.users[name].Id, hash); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tokenPair reads the token pair out of a login or refresh response
+func tokenPair(t *testing.T, rec *httptest.ResponseRecorder) auth.TokenPair {
+	t.Helper()
+	var body struct {
+		Data struct {
+			Data auth.TokenPair
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Data.Data
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/etg-dev/restApi/health"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// registerMongoChecks adds the mongo dependency checks to the readiness probe
+func registerMongoChecks(checker *health.Checker, client *mongo.Client, db *mongo.Database) {
+	checker.Register("mongo", func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	})
+
+	checker.Register("collections", func(ctx context.Context) error {
+		missing, err := missingCollections(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("missing collections: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	})
+
+	checker.Register("indexes", func(ctx context.Context) error {
+		missing, err := schema.MissingIndexes(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("missing indexes: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	})
+}
+
+// ensureCollections creates the collections mongo would otherwise only create on first insert
+func ensureCollections(ctx context.Context, db *mongo.Database) error {
+	missing, err := missingCollections(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, name := range missing {
+		if err := db.CreateCollection(ctx, name); err != nil {
+			return fmt.Errorf("creating collection %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func missingCollections(ctx context.Context, db *mongo.Database) ([]string, error) {
+	names, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+	for _, name := range names {
+		existing[name] = true
+	}
+
+	var missing []string
+	for _, name := range repositories.MongoCollections {
+		if !existing[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/etg-dev/restApi/logger"
+)
+
+// Hook is a named piece of work run when the application starts or stops
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// OnStart registers a hook that runs before the server accepts connections
+func (a *App) OnStart(name string, fn func(ctx context.Context) error) {
+	a.startHooks = append(a.startHooks, Hook{Name: name, Fn: fn})
+}
+
+// OnShutdown registers a hook that runs after the server stopped accepting requests.
+// Shutdown hooks run in reverse registration order.
+func (a *App) OnShutdown(name string, fn func(ctx context.Context) error) {
+	a.shutdownHooks = append(a.shutdownHooks, Hook{Name: name, Fn: fn})
+}
+
+// Run serves HTTP until ctx is cancelled, then drains in flight requests and runs the shutdown hooks
+func (a *App) Run(ctx context.Context) error {
+	for _, hook := range a.startHooks {
+		if err := hook.Fn(ctx); err != nil {
+			a.shutdown()
+			return fmt.Errorf("start hook %s: %w", hook.Name, err)
+		}
+	}
+
+	// request contexts derive from baseCtx so they can be cancelled when draining takes too long
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	server := a.Server()
+	server.BaseContext = func(net.Listener) context.Context { return baseCtx }
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Infof("listening on %s", server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+	a.Health.SetReady(true)
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		a.Health.SetReady(false)
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = err
+		}
+	case <-ctx.Done():
+		// report not ready first so the orchestrator stops routing new traffic here
+		a.Health.SetReady(false)
+		logger.Infof("shutting down, draining in flight requests")
+		drainCtx, cancel := context.WithTimeout(context.Background(), time.Duration(a.Config.Server.ShutdownTimeout))
+		if err := server.Shutdown(drainCtx); err != nil {
+			logger.Warnf("drain deadline exceeded, aborting remaining requests: %v", err)
+			cancelRequests()
+			_ = server.Close()
+		}
+		cancel()
+	}
+
+	if err := a.shutdown(); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+// shutdown runs the shutdown hooks newest first and returns the first failure
+func (a *App) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(a.Config.Server.ShutdownTimeout))
+	defer cancel()
+
+	var firstErr error
+	for i := len(a.shutdownHooks) - 1; i >= 0; i-- {
+		hook := a.shutdownHooks[i]
+		if err := hook.Fn(ctx); err != nil {
+			logger.Errorf("shutdown hook %s: %v", hook.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("shutdown hook %s: %w", hook.Name, err)
+			}
+		}
+	}
+	a.shutdownHooks = nil
+	return firstErr
+}
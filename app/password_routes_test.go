@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/permissions"
+)
+
+// apiKey creates an active key for the user and returns the secret
+func (f *fixture) apiKey(t *testing.T, name string) string {
+	t.Helper()
+	secret, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &models.APIKey{User: f.users[name].Id, Name: "ci", Prefix: prefix, KeyHash: hash, Scopes: []string{permissions.Read}, CreatedAt: time.Now().UTC()}
+	if err = f.repos.APIKeys.Create(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+// keyStatus is the status of a read with the API key
+func (f *fixture) keyStatus(key string) int {
+	req := httptest.NewRequest(http.MethodGet, "/api/posts/", nil)
+	req.Header.Set("Authorization", "ApiKey "+key)
+	rec := httptest.NewRecorder()
+	f.app.Router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+// waitForNextSecond makes sure tokens issued so far are older than a revocation, which has second precision
+func waitForNextSecond() {
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+}
+
+func TestChangePassword(t *testing.T) {
+	f := newFixture(t)
+	f.setPassword(t, "member", "old-secret-1")
+	key := f.apiKey(t, "member")
+	if status := f.keyStatus(key); status != http.StatusOK {
+		t.Fatalf("the API key before the change: status = %d, want %d", status, http.StatusOK)
+	}
+	waitForNextSecond()
+
+	steps := []struct {
+		name   string
+		as     string
+		body   string
+		status int
+		field  string
+	}{
+		{"anonymous can not change passwords", "anonymous", `{"oldPassword":"old-secret-1","newPassword":"new-secret-1"}`, http.StatusUnauthorized, ""},
+		{"the old password is required", "member", `{"newPassword":"new-secret-1"}`, http.StatusUnprocessableEntity, "oldPassword"},
+		{"the new password has a minimum length", "member", `{"oldPassword":"old-secret-1","newPassword":"short"}`, http.StatusUnprocessableEntity, "newPassword"},
+		{"the new password must follow the policy", "member", `{"oldPassword":"old-secret-1","newPassword":"no-digits-here"}`, http.StatusUnprocessableEntity, "newPassword"},
+		{"the old password must match", "member", `{"oldPassword":"wrong-secret-1","newPassword":"new-secret-1"}`, http.StatusUnauthorized, ""},
+		{"members change their password", "member", `{"oldPassword":"old-secret-1","newPassword":"new-secret-1"}`, http.StatusOK, ""},
+		{"the tokens from before the change are revoked", "member", `{"oldPassword":"new-secret-1","newPassword":"newer-secret-1"}`, http.StatusUnauthorized, ""},
+	}
+
+	for _, step := range steps {
+		rec := f.do(http.MethodPut, "/api/auth/password", step.as, step.body)
+		if rec.Code != step.status {
+			t.Fatalf("%s: status = %d, want %d, body %s", step.name, rec.Code, step.status, rec.Body)
+		}
+		if step.field != "" && !strings.Contains(rec.Body.String(), `"`+step.field+`"`) {
+			t.Errorf("%s: body %s has no error for %s", step.name, rec.Body, step.field)
+		}
+	}
+
+	rec := f.do(http.MethodPost, "/api/auth/login", "anonymous", `{"email":"member@example.com","password":"new-secret-1"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login with the new password: status = %d, body %s", rec.Code, rec.Body)
+	}
+	f.bearer["member"] = tokenPair(t, rec).AccessToken
+	if rec = f.do(http.MethodGet, "/api/posts/", "member", ""); rec.Code != http.StatusOK {
+		t.Errorf("the token issued after the change: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if status := f.keyStatus(key); status != http.StatusUnauthorized {
+		t.Errorf("the API key from before the change: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"known email", `{"email":"member@example.com"}`, http.StatusAccepted},
+		{"unknown emails get the same answer", `{"email":"nobody@example.com"}`, http.StatusAccepted},
+		{"missing email", `{}`, http.StatusUnprocessableEntity},
+		{"invalid email", `{"email":"member"}`, http.StatusUnprocessableEntity},
+	}
+
+	f := newFixture(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := f.do(http.MethodPost, "/api/auth/password/forgot", "anonymous", tt.body); rec.Code != tt.status {
+				t.Errorf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	f := newFixture(t)
+	token, hash, err := auth.NewResetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reset := &models.PasswordReset{User: f.users["member"].Id, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}
+	if err = f.repos.Resets.Create(context.Background(), reset); err != nil {
+		t.Fatal(err)
+	}
+	key := f.apiKey(t, "member")
+	unknown, _, err := auth.NewResetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForNextSecond()
+
+	steps := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"the token is required", `{"password":"new-secret-1"}`, http.StatusUnprocessableEntity},
+		{"the token has the mailed format", `{"token":"nope","password":"new-secret-1"}`, http.StatusUnprocessableEntity},
+		{"the password has a minimum length", `{"token":"` + token + `","password":"short"}`, http.StatusUnprocessableEntity},
+		{"unknown tokens", `{"token":"` + unknown + `","password":"new-secret-1"}`, http.StatusBadRequest},
+		{"reset", `{"token":"` + token + `","password":"new-secret-1"}`, http.StatusOK},
+		{"tokens are single use", `{"token":"` + token + `","password":"new-secret-2"}`, http.StatusBadRequest},
+	}
+
+	for _, step := range steps {
+		rec := f.do(http.MethodPost, "/api/auth/password/reset", "anonymous", step.body)
+		if rec.Code != step.status {
+			t.Fatalf("%s: status = %d, want %d, body %s", step.name, rec.Code, step.status, rec.Body)
+		}
+	}
+
+	if rec := f.do(http.MethodGet, "/api/posts/", "member", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("a token from before the reset: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if status := f.keyStatus(key); status != http.StatusUnauthorized {
+		t.Errorf("an API key from before the reset: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if rec := f.do(http.MethodPost, "/api/auth/login", "anonymous", `{"email":"member@example.com","password":"new-secret-1"}`); rec.Code != http.StatusOK {
+		t.Errorf("login with the new password: status = %d, body %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestPostRoutes(t *testing.T) {
+	post := `{"title":"new","content":"new content"}`
+
+	runRouteCases(t, []routeCase{
+		{"anonymous read the posts of a user", http.MethodGet, userPostsPath, "anonymous", "", http.StatusOK},
+		{"anonymous can not list posts", http.MethodGet, path("/api/posts/"), "anonymous", "", http.StatusUnauthorized},
+		{"members list posts", http.MethodGet, path("/api/posts/"), "member", "", http.StatusOK},
+		{"members search posts", http.MethodGet, path("/api/posts/search?q=title"), "member", "", http.StatusOK},
+		{"searches need a query", http.MethodGet, path("/api/posts/search"), "member", "", http.StatusBadRequest},
+		{"searches can not be paged with a cursor", http.MethodGet, path("/api/posts/search?q=title&after="), "member", "", http.StatusBadRequest},
+		{"anonymous can not create posts", http.MethodPost, path("/api/posts/"), "anonymous", post, http.StatusUnauthorized},
+		{"members create posts", http.MethodPost, path("/api/posts/"), "member", post, http.StatusCreated},
+		{"authors update their posts", http.MethodPatch, postPath("", false), "member", post, http.StatusOK},
+		{"members can not update the posts of others", http.MethodPatch, postPath("", false), "other", post, http.StatusForbidden},
+		{"admins update the posts of others", http.MethodPut, postPath("", false), "admin", post, http.StatusOK},
+		{"members can not delete the posts of others", http.MethodDelete, postPath("", false), "other", "", http.StatusForbidden},
+		{"authors delete their posts", http.MethodDelete, postPath("", false), "member", "", http.StatusOK},
+		{"anonymous can not restore posts", http.MethodPost, postPath("/restore", true), "anonymous", "", http.StatusUnauthorized},
+		{"authors can not restore posts", http.MethodPost, postPath("/restore", true), "member", "", http.StatusForbidden},
+		{"admins restore posts", http.MethodPost, postPath("/restore", true), "admin", "", http.StatusOK},
+		{"admins can not restore live posts", http.MethodPost, postPath("/restore", false), "admin", "", http.StatusNotFound},
+	})
+}
+
+// TestPostKeys checks that every post route renders posts with the json names of the model
+func TestPostKeys(t *testing.T) {
+	update := `{"title":"new"}`
+	tests := []struct {
+		name   string
+		method string
+		path   func(f *fixture) string
+		body   string
+		extra  []string
+	}{
+		{"list", http.MethodGet, path("/api/posts/"), "", []string{"commentCount"}},
+		{"list with a selection", http.MethodGet, path("/api/posts/?select=title,createdAt"), "", []string{"commentCount"}},
+		{"search", http.MethodGet, path("/api/posts/search?q=title"), "", []string{"commentCount", "score", "highlights"}},
+		{"user posts", http.MethodGet, userPostsPath, "", []string{"commentCount"}},
+		{"single post", http.MethodGet, func(f *fixture) string { return "/api/posts/item/" + f.post.Id.Hex() }, "", nil},
+		{"update", http.MethodPatch, postPath("", false), update, nil},
+		{"restore", http.MethodPost, postPath("/restore", true), "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			rec := f.do(tt.method, tt.path(f), "admin", tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+			keys := append([]string{"id", "title", "createdAt"}, tt.extra...)
+			checkKeys(t, firstItem(t, rec.Body.Bytes()), keys, []string{"_id", "Id", "Title", "created_at", "comment_count"})
+		})
+	}
+}
+
+// firstItem returns the rendered document of a response, or the first one of a list
+func firstItem(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+	var response struct {
+		Data struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatal(err)
+	}
+	var list []map[string]interface{}
+	if err := json.Unmarshal(response.Data.Data, &list); err == nil {
+		if len(list) == 0 {
+			t.Fatalf("body %s has an empty list", body)
+		}
+		return list[0]
+	}
+	var item map[string]interface{}
+	if err := json.Unmarshal(response.Data.Data, &item); err != nil {
+		t.Fatalf("body %s holds no document: %v", body, err)
+	}
+	return item
+}
+
+func checkKeys(t *testing.T, item map[string]interface{}, keys, absent []string) {
+	t.Helper()
+	for _, key := range keys {
+		if _, ok := item[key]; !ok {
+			t.Errorf("item %v has no %s", item, key)
+		}
+	}
+	for _, key := range absent {
+		if _, ok := item[key]; ok {
+			t.Errorf("item %v has %s", item, key)
+		}
+	}
+}
+
+func userPostsPath(f *fixture) string {
+	return "/api/posts/user/" + f.users["member"].Id.Hex()
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/logger"
+	"github.com/etg-dev/restApi/repositories"
+)
+
+// purgeTimeout bounds a single purge run so a slow database can not pile runs up
+const purgeTimeout = 5 * time.Minute
+
+// schedulePurge starts the job that removes soft deleted users, posts and comments once the retention window passed
+func (a *App) schedulePurge() {
+	interval := time.Duration(a.Config.SoftDelete.PurgeInterval)
+	if interval <= 0 {
+		return
+	}
+	retention := time.Duration(a.Config.SoftDelete.Retention)
+
+	var stop context.CancelFunc
+	done := make(chan struct{})
+	a.OnStart("purge", func(context.Context) error {
+		var ctx context.Context
+		ctx, stop = context.WithCancel(context.Background())
+		go func() {
+			defer close(done)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					runCtx, cancel := context.WithTimeout(ctx, purgeTimeout)
+					if err := purgeDeleted(runCtx, a.Repos, time.Now().UTC().Add(-retention)); err != nil {
+						logger.Errorf("purging deleted records: %v", err)
+					}
+					cancel()
+				}
+			}
+		}()
+		return nil
+	})
+	a.OnShutdown("purge", func(ctx context.Context) error {
+		// a failed start hook before this one means the job never ran
+		if stop == nil {
+			return nil
+		}
+		stop()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// purgeDeleted removes users, posts and comments soft deleted before the given time, a
+// user's comments, posts, actions and API keys go first so an interrupted run is finished by the next
+func purgeDeleted(ctx context.Context, repos *repositories.Repositories, before time.Time) error {
+	ids, err := repos.Users.DeletedBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = repos.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repos.Comments.PurgeByUser(ctx, id); err != nil {
+				return err
+			}
+			if err := repos.Posts.PurgeByUser(ctx, id); err != nil {
+				return err
+			}
+			if err := repos.Actions.DeleteByUser(ctx, id); err != nil {
+				return err
+			}
+			if err := repos.APIKeys.DeleteByUser(ctx, id); err != nil {
+				return err
+			}
+			if err := repos.Users.Purge(ctx, id); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	posts, err := repos.Posts.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	// the comments of purged posts go last, they were hidden since their post was deleted
+	comments, err := repos.Comments.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+	orphans, err := repos.Comments.PurgeOrphans(ctx)
+	if err != nil {
+		return err
+	}
+	comments += orphans
+
+	if len(ids) > 0 || posts > 0 || comments > 0 {
+		logger.Infof("purged %d users, %d posts and %d comments deleted before %s", len(ids), posts, comments, before.Format(time.RFC3339))
+	}
+	return nil
+}
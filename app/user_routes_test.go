@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUserRoutes(t *testing.T) {
+	newUser := `{"name":"new","email":"new@example.com","password":"secret-pass-1","action":["Admin"]}`
+	update := `{"name":"renamed","email":"renamed@example.com"}`
+	actions := `{"actions":["Read"]}`
+
+	runRouteCases(t, []routeCase{
+		{"anonymous can list users", http.MethodGet, path("/api/users/"), "anonymous", "", http.StatusOK},
+		{"anonymous can not create users", http.MethodPost, path("/api/users/"), "anonymous", newUser, http.StatusUnauthorized},
+		{"members can not create users", http.MethodPost, path("/api/users/"), "member", newUser, http.StatusForbidden},
+		{"admins create users", http.MethodPost, path("/api/users/"), "admin", newUser, http.StatusCreated},
+		{"anonymous can not update users", http.MethodPut, userPath("member", ""), "anonymous", update, http.StatusUnauthorized},
+		{"members update themselves", http.MethodPut, userPath("member", ""), "member", update, http.StatusOK},
+		{"members can not update others", http.MethodPut, userPath("other", ""), "member", update, http.StatusForbidden},
+		{"admins update others", http.MethodPut, userPath("other", ""), "admin", update, http.StatusOK},
+		{"members can not delete others", http.MethodDelete, userPath("other", ""), "member", "", http.StatusForbidden},
+		{"members delete themselves", http.MethodDelete, userPath("member", ""), "member", "", http.StatusOK},
+		{"members read their own actions", http.MethodGet, userPath("member", "/actions"), "member", "", http.StatusOK},
+		{"members can not read the actions of others", http.MethodGet, userPath("other", "/actions"), "member", "", http.StatusForbidden},
+		{"members can not grant actions", http.MethodPut, userPath("member", "/actions"), "member", actions, http.StatusForbidden},
+		{"admins grant actions", http.MethodPut, userPath("member", "/actions"), "admin", actions, http.StatusOK},
+		{"members can not restore users", http.MethodPost, userPath("other", "/restore"), "member", "", http.StatusForbidden},
+	})
+}
+
+// TestUserKeys checks that every user route renders users with the json names of the model
+func TestUserKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   func(f *fixture) string
+		body   string
+	}{
+		{"list", http.MethodGet, path("/api/users/"), ""},
+		{"list with a selection", http.MethodGet, path("/api/users/?select=name,createdAt"), ""},
+		{"single user", http.MethodGet, userPath("member", ""), ""},
+		{"update", http.MethodPut, userPath("member", ""), `{"name":"renamed","email":"renamed@example.com"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			rec := f.do(tt.method, tt.path(f), "admin", tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+			checkKeys(t, firstItem(t, rec.Body.Bytes()), []string{"id", "name", "createdAt"}, []string{"_id", "Id", "Name", "created_at", "passwordHash"})
+		})
+	}
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	// the member's comment and the reply below it sit on a post of another user
+	post := &models.Post{Title: "title", Content: "content", User: f.users["other"].Id}
+	if err := f.repos.Posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	comment := &models.Comment{Post: post.Id, User: f.users["member"].Id, Body: "by the member"}
+	if err := f.repos.Comments.Create(ctx, comment); err != nil {
+		t.Fatal(err)
+	}
+	reply := &models.Comment{Post: post.Id, User: f.users["other"].Id, Body: "a reply", Parent: &comment.Id, Ancestors: []primitive.ObjectID{comment.Id}}
+	if err := f.repos.Comments.Create(ctx, reply); err != nil {
+		t.Fatal(err)
+	}
+	register := `{"name":"again","email":"member@example.com","password":"secret-pass-1"}`
+	commentsPath := "/api/posts/" + post.Id.Hex() + "/comments/"
+	memberPath := "/api/users/" + f.users["member"].Id.Hex()
+
+	steps := []struct {
+		name     string
+		method   string
+		path     string
+		as       string
+		body     string
+		status   int
+		comments bool
+	}{
+		{"the comments are visible", http.MethodGet, commentsPath, "other", "", http.StatusOK, true},
+		{"members delete themselves", http.MethodDelete, memberPath, "member", "", http.StatusOK, false},
+		{"their comments and the replies below are hidden", http.MethodGet, commentsPath, "other", "", http.StatusOK, false},
+		{"their email stays taken until the purge", http.MethodPost, "/api/auth/register", "anonymous", register, http.StatusConflict, false},
+		{"admins restore the user", http.MethodPost, memberPath + "/restore", "admin", "", http.StatusOK, false},
+		{"the comments are back", http.MethodGet, commentsPath, "other", "", http.StatusOK, true},
+	}
+
+	for _, step := range steps {
+		rec := f.do(step.method, step.path, step.as, step.body)
+		if rec.Code != step.status {
+			t.Fatalf("%s: status = %d, want %d, body %s", step.name, rec.Code, step.status, rec.Body)
+		}
+		if step.method != http.MethodGet {
+			continue
+		}
+		for _, body := range []string{comment.Body, reply.Body} {
+			if strings.Contains(rec.Body.String(), body) != step.comments {
+				t.Errorf("%s: body %s, want %q shown %v", step.name, rec.Body, body, step.comments)
+			}
+		}
+	}
+
+	if rec := f.do(http.MethodDelete, memberPath, "admin", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete again: status = %d, body %s", rec.Code, rec.Body)
+	}
+	if err := purgeDeleted(ctx, f.repos, time.Now().UTC().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if rec := f.do(http.MethodPost, "/api/auth/register", "anonymous", register); rec.Code != http.StatusCreated {
+		t.Errorf("register after the purge: status = %d, want %d, body %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+}
+
+func TestRoleRoutes(t *testing.T) {
+	role := `{"name":"reviewers","permissions":["Read"]}`
+	rolePath := func(f *fixture) string { return "/api/roles/" + f.role.Id.Hex() }
+
+	runRouteCases(t, []routeCase{
+		{"anonymous can not list roles", http.MethodGet, path("/api/roles/"), "anonymous", "", http.StatusUnauthorized},
+		{"members list roles", http.MethodGet, path("/api/roles/"), "member", "", http.StatusOK},
+		{"members read a role", http.MethodGet, rolePath, "member", "", http.StatusOK},
+		{"members can not create roles", http.MethodPost, path("/api/roles/"), "member", role, http.StatusForbidden},
+		{"admins create roles", http.MethodPost, path("/api/roles/"), "admin", role, http.StatusCreated},
+		{"members can not delete roles", http.MethodDelete, rolePath, "member", "", http.StatusForbidden},
+		{"admins delete roles", http.MethodDelete, rolePath, "admin", "", http.StatusOK},
+	})
+}
@@ -0,0 +1,142 @@
+package apperrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound is the storage error for a document that does not exist, repositories return it
+// so the handlers can map it without depending on the storage packages
+var ErrNotFound = errors.New("document not found")
+
+// ErrDuplicate is the storage error for a write that would break a uniqueness rule
+var ErrDuplicate = errors.New("duplicate document")
+
+// Code is the machine readable reason of an error, clients switch on it instead of the message
+type Code string
+
+const (
+	CodeBadRequest   Code = "bad_request"
+	CodeInvalidID    Code = "invalid_id"
+	CodeValidation   Code = "validation_failed"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodeTimeout      Code = "timeout"
+	CodeInternal     Code = "internal_error"
+)
+
+// Error is an error that knows how it is rendered to the client.
+// Message is safe to show, the wrapped Err is only logged.
+type Error struct {
+	Code    Code
+	Status  int
+	Message string
+	// Fields maps request fields to what is wrong with them
+	Fields map[string]string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap keeps the cause of the error for the logs
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func New(status int, code Code, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// InvalidID is returned when a path or body value is not a valid ObjectID
+func InvalidID(name string, err error) *Error {
+	return New(http.StatusBadRequest, CodeInvalidID, fmt.Sprintf("%s must be a valid id", name)).Wrap(err)
+}
+
+// Validation is returned when the request is well formed but its content is not acceptable
+func Validation(message string, fields map[string]string) *Error {
+	e := New(http.StatusUnprocessableEntity, CodeValidation, message)
+	e.Fields = fields
+	return e
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+func Internal(err error) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, "Something went wrong").Wrap(err)
+}
+
+// Bind turns a failed request body bind into a 400 for unreadable bodies and a 422 for invalid ones
+func Bind(err error) *Error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var invalid validator.ValidationErrors
+	switch {
+	case errors.As(err, &invalid):
+		fields := map[string]string{}
+		for _, fe := range invalid {
+			fields[fe.Field()] = fmt.Sprintf("failed the %s rule", fe.Tag())
+		}
+		return Validation("Request body failed validation", fields).Wrap(err)
+	case errors.Is(err, io.EOF):
+		return BadRequest("Request body is required").Wrap(err)
+	case errors.As(err, &syntaxErr):
+		return BadRequest("Request body is not valid JSON").Wrap(err)
+	case errors.As(err, &typeErr):
+		return Validation("Request body has the wrong type", map[string]string{typeErr.Field: fmt.Sprintf("must be %s", typeErr.Type)}).Wrap(err)
+	}
+	return BadRequest("Request body could not be read").Wrap(err)
+}
+
+// From maps any error onto an Error. Repository and driver errors get their status,
+// anything unknown becomes a 500 that does not leak the original message.
+func From(err error) *Error {
+	var appErr *Error
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, ErrNotFound), errors.Is(err, mongo.ErrNoDocuments):
+		return NotFound("Resource not found").Wrap(err)
+	case errors.Is(err, ErrDuplicate), mongo.IsDuplicateKeyError(err):
+		return Conflict("Resource already exists").Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded), mongo.IsTimeout(err):
+		return New(http.StatusGatewayTimeout, CodeTimeout, "The request took too long").Wrap(err)
+	}
+	return Internal(err)
+}
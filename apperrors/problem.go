@@ -0,0 +1,84 @@
+package apperrors
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// problemTypeBase prefixes the code to form the problem type, GET on the type describes it
+const problemTypeBase = "/problems/"
+
+// Problem is the RFC 7807 rendering of an Error, Code and Errors are extension members
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     Code         `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// ProblemType describes a problem type for the clients that dereference it
+type ProblemType struct {
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+var problemTypes = map[Code]ProblemType{
+	CodeBadRequest:   {Title: "Bad Request", Status: http.StatusBadRequest, Description: "The request could not be read, for example the body is not valid JSON or a query parameter is malformed."},
+	CodeInvalidID:    {Title: "Invalid Id", Status: http.StatusBadRequest, Description: "A path parameter or field that must be an ObjectID is not a 24 character hex string."},
+	CodeValidation:   {Title: "Validation Failed", Status: http.StatusUnprocessableEntity, Description: "The request is well formed but some fields are not acceptable, the errors member lists them."},
+	CodeUnauthorized: {Title: "Unauthorized", Status: http.StatusUnauthorized, Description: "The request has no valid access token or API key, or the credentials are wrong."},
+	CodeForbidden:    {Title: "Forbidden", Status: http.StatusForbidden, Description: "The authenticated user lacks the action or ownership the request needs."},
+	CodeNotFound:     {Title: "Not Found", Status: http.StatusNotFound, Description: "The resource or route does not exist."},
+	CodeConflict:     {Title: "Conflict", Status: http.StatusConflict, Description: "The request would break a uniqueness rule, for example an email that is already registered."},
+	CodeTimeout:      {Title: "Timeout", Status: http.StatusGatewayTimeout, Description: "The request did not finish in time, it is safe to retry idempotent requests."},
+	CodeInternal:     {Title: "Internal Server Error", Status: http.StatusInternalServerError, Description: "Something unexpected failed on the server, the details are in the server log."},
+}
+
+// TypeOf returns the problem type URI of the code
+func TypeOf(code Code) string {
+	return problemTypeBase + strings.ReplaceAll(string(code), "_", "-")
+}
+
+// LookupProblemType finds the problem type from the last segment of its URI
+func LookupProblemType(name string) (ProblemType, bool) {
+	code := Code(strings.ReplaceAll(name, "-", "_"))
+	pt, ok := problemTypes[code]
+	pt.Type = TypeOf(code)
+	return pt, ok
+}
+
+// Problem renders the error as problem details for the request path in instance
+func (e *Error) Problem(instance string) Problem {
+	title := http.StatusText(e.Status)
+	if pt, ok := problemTypes[e.Code]; ok {
+		title = pt.Title
+	}
+
+	p := Problem{
+		Type:     TypeOf(e.Code),
+		Title:    title,
+		Status:   e.Status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+	}
+	for field, detail := range e.Fields {
+		p.Errors = append(p.Errors, FieldError{Field: field, Detail: detail})
+	}
+	sort.Slice(p.Errors, func(i, j int) bool { return p.Errors[i].Field < p.Errors[j].Field })
+	return p
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/etg-dev/restApi/logger"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recorder writes audit entries to the log and the audit repository
+type Recorder struct {
+	entries repositories.AuditRepository
+}
+
+func NewRecorder(entries repositories.AuditRepository) *Recorder {
+	return &Recorder{entries: entries}
+}
+
+// Record stores the entry, a failure is logged but never undoes the audited change
+func (r *Recorder) Record(ctx context.Context, entry models.AuditEntry) {
+	if entry.At.IsZero() {
+		entry.At = time.Now().UTC()
+	}
+
+	actor := "anonymous"
+	if entry.Actor != nil {
+		actor = entry.Actor.Hex()
+	}
+	logger.Infof("audit: %s %s %s by %s before=%v after=%v", entry.Event, entry.Resource, entry.ResourceId.Hex(), actor, entry.Before, entry.After)
+
+	if err := r.entries.Create(ctx, &entry); err != nil {
+		logger.Errorf("audit: storing %s entry failed: %v", entry.Event, err)
+	}
+}
+
+// Actor returns the id of the user behind the request, if one was identified
+func Actor(c *gin.Context) *primitive.ObjectID {
+	if id, ok := c.Value("userId").(primitive.ObjectID); ok {
+		return &id
+	}
+	return nil
+}
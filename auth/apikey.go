@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// APIKeyPrefix marks our keys so they are easy to spot in logs and secret scanners
+const APIKeyPrefix = "rak_"
+
+// apiKeyDisplayLength is how much of the key is kept in clear to tell keys apart
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// NewAPIKey returns a random key, the part of it that may be shown again and the hash that is stored
+func NewAPIKey() (key string, prefix string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + hex.EncodeToString(b)
+	return key, key[:apiKeyDisplayLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes an API key for lookup
+func HashAPIKey(key string) string {
+	return hashSecret(key)
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials hides whether the email or the password was wrong
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// HashPassword hashes the password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// dummyHash is compared against when there is no stored hash, so a login for an unknown
+// email or a user without a password takes as long as one with a wrong password
+const dummyHash = "$2a$10$PsEBTHCoTniwvCE81cZQQ.4CgBh9aQAViLFnboa7ODCeMLk7ZL.BG"
+
+// CheckPassword compares a password with a stored hash, an empty hash never matches
+func CheckPassword(hash, password string) error {
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+		return ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/etg-dev/restApi/configs"
+)
+
+// maxPasswordBytes is where bcrypt stops reading
+const maxPasswordBytes = 72
+
+// CheckPolicy returns one message per rule the password breaks
+func CheckPolicy(policy configs.PasswordConfig, password string) []string {
+	var problems []string
+	if len([]rune(password)) < policy.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters", policy.MinLength))
+	}
+	if len(password) > maxPasswordBytes {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes", maxPasswordBytes))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if policy.RequireUpper && !upper {
+		problems = append(problems, "must contain an upper case letter")
+	}
+	if policy.RequireLower && !lower {
+		problems = append(problems, "must contain a lower case letter")
+	}
+	if policy.RequireDigit && !digit {
+		problems = append(problems, "must contain a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		problems = append(problems, "must contain a symbol")
+	}
+	return problems
+}
+
+// PolicyMessage joins the policy problems into one sentence
+func PolicyMessage(problems []string) string {
+	return "password " + strings.Join(problems, ", ")
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// NewResetToken returns a random token for the user and the hash that is stored instead of it
+func NewResetToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, HashResetToken(token), nil
+}
+
+// HashResetToken hashes a reset token for lookup
+func HashResetToken(token string) string {
+	return hashSecret(token)
+}
+
+// hashSecret is enough for random secrets, unlike passwords they need neither salt nor a slow hash
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+// ErrInvalidToken covers malformed, expired, revoked and wrongly typed tokens
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the JWT claims issued by this service, the subject is the user id
+type Claims struct {
+	Type string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user the token was issued to
+func (c *Claims) UserID() (primitive.ObjectID, error) {
+	return primitive.ObjectIDFromHex(c.Subject)
+}
+
+// TokenPair is returned on login and refresh
+type TokenPair struct {
+	AccessToken      string    `json:"accessToken"`
+	RefreshToken     string    `json:"refreshToken"`
+	TokenType        string    `json:"tokenType"`
+	AccessExpiresAt  time.Time `json:"accessExpiresAt"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// TokenService issues, verifies and revokes signed access and refresh tokens
+type TokenService struct {
+	secret     []byte
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	revoked    repositories.TokenRepository
+}
+
+func NewTokenService(cfg configs.AuthConfig, revoked repositories.TokenRepository) *TokenService {
+	return &TokenService{
+		secret:     []byte(cfg.JWTSecret),
+		issuer:     cfg.Issuer,
+		accessTTL:  time.Duration(cfg.AccessTTL),
+		refreshTTL: time.Duration(cfg.RefreshTTL),
+		revoked:    revoked,
+	}
+}
+
+// Issue signs a new access and refresh token for the user
+func (s *TokenService) Issue(userID primitive.ObjectID) (*TokenPair, error) {
+	now := time.Now()
+
+	access, accessExpiresAt, err := s.sign(userID, AccessToken, now, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, refreshExpiresAt, err := s.sign(userID, RefreshToken, now, s.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// Verify checks signature, expiry, type and revocation of the token
+func (s *TokenService) Verify(ctx context.Context, token, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(s.issuer))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: missing expiry", ErrInvalidToken)
+	}
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("%w: expected %s token", ErrInvalidToken, tokenType)
+	}
+	if _, err := claims.UserID(); err != nil {
+		return nil, fmt.Errorf("%w: bad subject", ErrInvalidToken)
+	}
+
+	revoked, err := s.revoked.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, fmt.Errorf("%w: token revoked", ErrInvalidToken)
+	}
+
+	userID, _ := claims.UserID()
+	before, err := s.revoked.RevokedBefore(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if claims.IssuedAt == nil || claims.IssuedAt.Time.Before(before) {
+		return nil, fmt.Errorf("%w: token revoked", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// Revoke blocks the token until it expires
+func (s *TokenService) Revoke(ctx context.Context, claims *Claims) error {
+	userID, _ := claims.UserID()
+	return s.revoked.Revoke(ctx, &models.RevokedToken{
+		Jti:       claims.ID,
+		User:      userID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	})
+}
+
+// RevokeUser blocks every token issued to the user so far, used when the password changes.
+// Issue times have second precision, tokens issued later in the same second stay valid so a
+// login right after the change works.
+func (s *TokenService) RevokeUser(ctx context.Context, userID primitive.ObjectID) error {
+	before := time.Now().Truncate(time.Second)
+	return s.revoked.RevokeUser(ctx, userID, before, before.Add(s.refreshTTL))
+}
+
+func (s *TokenService) sign(userID primitive.ObjectID, tokenType string, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := now.Add(ttl)
+	claims := Claims{
+		Type: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    s.issuer,
+			Subject:   userID.Hex(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
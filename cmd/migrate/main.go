@@ -0,0 +1,111 @@
+// Command migrate applies and reverts the versioned database migrations.
+//
+//	migrate up|down|status [config flags]
+//	migrate to N [config flags]
+//
+// The config flags are the ones the server takes, e.g. -config or -mongo-uri.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/migrations"
+)
+
+const usage = "usage: migrate up|down|status|to N [config flags]"
+
+func main() {
+	// run returns instead of exiting so its deferred disconnect runs before the process ends
+	if err := run(os.Args[1:]); err != nil {
+		log.Print(err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	command, args := args[0], args[1:]
+
+	target := 0
+	if command == "to" {
+		if len(args) == 0 {
+			return errors.New(usage)
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < 0 {
+			return fmt.Errorf("migrate to: %q is not a version", args[0])
+		}
+		target, args = version, args[1:]
+	}
+
+	cfg, err := configs.Load(args)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if cfg.Storage != configs.StorageMongo {
+		return fmt.Errorf("migrations need mongo storage, the %s profile uses %s", cfg.Env, cfg.Storage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client, err := configs.ConnectDB(ctx, cfg.ConnectOptions())
+	if err != nil {
+		return fmt.Errorf("connecting to mongo: %w", err)
+	}
+	defer func() {
+		disconnectCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := client.Disconnect(disconnectCtx); err != nil {
+			log.Printf("disconnecting from mongo: %v", err)
+		}
+	}()
+
+	runner := migrations.NewRunner(configs.GetDatabase(client, cfg.Mongo.Database))
+	switch command {
+	case "up":
+		err = runner.Up(ctx)
+	case "down":
+		err = runner.Down(ctx)
+	case "to":
+		err = runner.To(ctx, target)
+	case "status":
+		err = printStatus(ctx, runner)
+	default:
+		err = fmt.Errorf("unknown command %q, %s", command, usage)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", command, err)
+	}
+	return nil
+}
+
+func printStatus(ctx context.Context, runner *migrations.Runner) error {
+	states, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, state := range states {
+		applied := "pending"
+		if state.AppliedAt != nil {
+			applied = state.AppliedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", state.Version, state.Name, applied)
+	}
+	return w.Flush()
+}
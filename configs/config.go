@@ -0,0 +1,325 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/etg-dev/restApi/logger"
+	"github.com/etg-dev/restApi/permissions"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EnvDev  = "dev"
+	EnvTest = "test"
+	EnvProd = "prod"
+
+	StorageMongo  = "mongo"
+	StorageMemory = "memory"
+
+	CascadeDelete   = "delete"
+	CascadeReassign = "reassign"
+	CascadeBlock    = "block"
+)
+
+// Config is the full runtime configuration of the service
+type Config struct {
+	Env        string           `yaml:"env" toml:"env"`
+	Storage    string           `yaml:"storage" toml:"storage"`
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Mongo      MongoConfig      `yaml:"mongo" toml:"mongo"`
+	Pagination PaginationConfig `yaml:"pagination" toml:"pagination"`
+	Log        LogConfig        `yaml:"log" toml:"log"`
+	Auth       AuthConfig       `yaml:"auth" toml:"auth"`
+	Mail       MailConfig       `yaml:"mail" toml:"mail"`
+	SoftDelete SoftDeleteConfig `yaml:"softDelete" toml:"softDelete"`
+	Cascade    CascadeConfig    `yaml:"cascade" toml:"cascade"`
+}
+
+type ServerConfig struct {
+	Addr         string   `yaml:"addr" toml:"addr"`
+	ReadTimeout  Duration `yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout Duration `yaml:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout  Duration `yaml:"idleTimeout" toml:"idleTimeout"`
+	// ShutdownTimeout bounds how long in flight requests may drain on SIGINT/SIGTERM
+	ShutdownTimeout Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+}
+
+type MongoConfig struct {
+	URI             string   `yaml:"uri" toml:"uri"`
+	Database        string   `yaml:"database" toml:"database"`
+	ConnectAttempts int      `yaml:"connectAttempts" toml:"connectAttempts"`
+	ConnectTimeout  Duration `yaml:"connectTimeout" toml:"connectTimeout"`
+	InitialBackoff  Duration `yaml:"initialBackoff" toml:"initialBackoff"`
+	MaxBackoff      Duration `yaml:"maxBackoff" toml:"maxBackoff"`
+}
+
+type PaginationConfig struct {
+	DefaultPageSize int64 `yaml:"defaultPageSize" toml:"defaultPageSize"`
+	MaxPageSize     int64 `yaml:"maxPageSize" toml:"maxPageSize"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level"`
+}
+
+type AuthConfig struct {
+	// JWTSecret signs access and refresh tokens with HS256
+	JWTSecret  string   `yaml:"jwtSecret" toml:"jwtSecret"`
+	Issuer     string   `yaml:"issuer" toml:"issuer"`
+	AccessTTL  Duration `yaml:"accessTTL" toml:"accessTTL"`
+	RefreshTTL Duration `yaml:"refreshTTL" toml:"refreshTTL"`
+	// DefaultActions are granted to users who register themselves
+	DefaultActions []string       `yaml:"defaultActions" toml:"defaultActions"`
+	Password       PasswordConfig `yaml:"password" toml:"password"`
+}
+
+// PasswordConfig is the password policy and the lifetime of reset tokens
+type PasswordConfig struct {
+	MinLength     int      `yaml:"minLength" toml:"minLength"`
+	RequireUpper  bool     `yaml:"requireUpper" toml:"requireUpper"`
+	RequireLower  bool     `yaml:"requireLower" toml:"requireLower"`
+	RequireDigit  bool     `yaml:"requireDigit" toml:"requireDigit"`
+	RequireSymbol bool     `yaml:"requireSymbol" toml:"requireSymbol"`
+	ResetTokenTTL Duration `yaml:"resetTokenTTL" toml:"resetTokenTTL"`
+}
+
+type MailConfig struct {
+	// Driver is "log" to write mails to the log or "file" to drop them in Dir
+	Driver string `yaml:"driver" toml:"driver"`
+	Dir    string `yaml:"dir" toml:"dir"`
+	From   string `yaml:"from" toml:"from"`
+	// ResetURL is the frontend page that takes the reset token, %s is replaced by the token
+	ResetURL string `yaml:"resetURL" toml:"resetURL"`
+}
+
+// SoftDeleteConfig controls how long deleted users and posts can be restored
+type SoftDeleteConfig struct {
+	// Retention is how long a deleted record is kept before the purge job removes it for good
+	Retention Duration `yaml:"retention" toml:"retention"`
+	// PurgeInterval is how often the purge job runs, zero turns it off
+	PurgeInterval Duration `yaml:"purgeInterval" toml:"purgeInterval"`
+}
+
+// CascadeConfig decides what deleting a user does to the posts they wrote
+type CascadeConfig struct {
+	// Posts is "delete" to delete them with the user, "reassign" to hand them to
+	// ReassignTo or "block" to refuse deleting a user who still has posts
+	Posts string `yaml:"posts" toml:"posts"`
+	// ReassignTo is the id of the user that receives the posts when Posts is "reassign"
+	ReassignTo string `yaml:"reassignTo" toml:"reassignTo"`
+}
+
+// insecureDevSecret is only good enough for local development and tests
+const insecureDevSecret = "dev-secret-do-not-use-in-production"
+
+// Duration is a time.Duration that reads from strings such as "10s" in config files
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Defaults returns the configuration profile for the given environment
+func Defaults(env string) Config {
+	cfg := Config{
+		Env:     env,
+		Storage: StorageMongo,
+		Server: ServerConfig{
+			Addr:         "localhost:6000",
+			ReadTimeout:  Duration(15 * time.Second),
+			WriteTimeout: Duration(15 * time.Second),
+			IdleTimeout:  Duration(60 * time.Second),
+
+			ShutdownTimeout: Duration(20 * time.Second),
+		},
+		Mongo: MongoConfig{
+			Database:        "blogDB",
+			ConnectAttempts: 5,
+			ConnectTimeout:  Duration(10 * time.Second),
+			InitialBackoff:  Duration(500 * time.Millisecond),
+			MaxBackoff:      Duration(8 * time.Second),
+		},
+		Pagination: PaginationConfig{
+			DefaultPageSize: 10,
+			MaxPageSize:     100,
+		},
+		Log: LogConfig{
+			Level: "debug",
+		},
+		Auth: AuthConfig{
+			JWTSecret:  insecureDevSecret,
+			Issuer:     "restApi",
+			AccessTTL:  Duration(15 * time.Minute),
+			RefreshTTL: Duration(7 * 24 * time.Hour),
+
+			DefaultActions: []string{"Read", "Create", "Update", "Delete"},
+			Password: PasswordConfig{
+				MinLength:     8,
+				RequireLower:  true,
+				RequireDigit:  true,
+				ResetTokenTTL: Duration(time.Hour),
+			},
+		},
+		Mail: MailConfig{
+			Driver:   "log",
+			Dir:      "mail",
+			From:     "no-reply@localhost",
+			ResetURL: "http://localhost:3000/reset-password?token=%s",
+		},
+		SoftDelete: SoftDeleteConfig{
+			Retention:     Duration(30 * 24 * time.Hour),
+			PurgeInterval: Duration(time.Hour),
+		},
+		Cascade: CascadeConfig{
+			Posts: CascadeDelete,
+		},
+	}
+
+	switch env {
+	case EnvTest:
+		cfg.Storage = StorageMemory
+		cfg.Mongo.Database = "blogDB_test"
+		cfg.Mongo.ConnectAttempts = 1
+		cfg.Log.Level = "warn"
+	case EnvProd:
+		cfg.Server.Addr = ":6000"
+		cfg.Log.Level = "info"
+		cfg.Auth.JWTSecret = ""
+	}
+	return cfg
+}
+
+// Validate reports every problem with the configuration at once
+func (cfg *Config) Validate() error {
+	var problems []string
+
+	switch cfg.Env {
+	case EnvDev, EnvTest, EnvProd:
+	default:
+		problems = append(problems, fmt.Sprintf("env must be one of dev, test, prod, got %q", cfg.Env))
+	}
+
+	switch cfg.Storage {
+	case StorageMongo:
+		if cfg.Mongo.URI == "" {
+			problems = append(problems, "mongo.uri is required when storage is mongo")
+		}
+		if cfg.Mongo.Database == "" {
+			problems = append(problems, "mongo.database is required when storage is mongo")
+		}
+		if cfg.Mongo.ConnectAttempts < 1 {
+			problems = append(problems, "mongo.connectAttempts must be at least 1")
+		}
+		if cfg.Mongo.ConnectTimeout <= 0 {
+			problems = append(problems, "mongo.connectTimeout must be positive")
+		}
+		if cfg.Mongo.InitialBackoff <= 0 {
+			problems = append(problems, "mongo.initialBackoff must be positive")
+		}
+		if cfg.Mongo.MaxBackoff < cfg.Mongo.InitialBackoff {
+			problems = append(problems, "mongo.maxBackoff must not be smaller than mongo.initialBackoff")
+		}
+	case StorageMemory:
+		if cfg.Env == EnvProd {
+			problems = append(problems, "storage memory is not allowed in prod")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("storage must be mongo or memory, got %q", cfg.Storage))
+	}
+
+	if cfg.Server.Addr == "" {
+		problems = append(problems, "server.addr is required")
+	}
+	if cfg.Server.ReadTimeout <= 0 || cfg.Server.WriteTimeout <= 0 || cfg.Server.IdleTimeout <= 0 || cfg.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server timeouts must be positive")
+	}
+
+	if cfg.Pagination.DefaultPageSize < 1 {
+		problems = append(problems, "pagination.defaultPageSize must be at least 1")
+	}
+	if cfg.Pagination.MaxPageSize < cfg.Pagination.DefaultPageSize {
+		problems = append(problems, "pagination.maxPageSize must not be smaller than pagination.defaultPageSize")
+	}
+
+	if len(cfg.Auth.JWTSecret) < 32 {
+		problems = append(problems, "auth.jwtSecret must be at least 32 characters")
+	}
+	if cfg.Env == EnvProd && cfg.Auth.JWTSecret == insecureDevSecret {
+		problems = append(problems, "auth.jwtSecret must be changed in prod")
+	}
+	if cfg.Auth.AccessTTL <= 0 || cfg.Auth.RefreshTTL <= cfg.Auth.AccessTTL {
+		problems = append(problems, "auth.accessTTL must be positive and shorter than auth.refreshTTL")
+	}
+	if unknown := permissions.Unknown(cfg.Auth.DefaultActions); len(unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("auth.defaultActions has unknown actions %s", strings.Join(unknown, ", ")))
+	}
+	// bcrypt only looks at the first 72 bytes of a password
+	if cfg.Auth.Password.MinLength < 6 || cfg.Auth.Password.MinLength > 72 {
+		problems = append(problems, "auth.password.minLength must be between 6 and 72")
+	}
+	if cfg.Auth.Password.ResetTokenTTL <= 0 {
+		problems = append(problems, "auth.password.resetTokenTTL must be positive")
+	}
+
+	switch cfg.Mail.Driver {
+	case "log":
+	case "file":
+		if cfg.Mail.Dir == "" {
+			problems = append(problems, "mail.dir is required when mail.driver is file")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("mail.driver must be log or file, got %q", cfg.Mail.Driver))
+	}
+	if !strings.Contains(cfg.Mail.ResetURL, "%s") {
+		problems = append(problems, "mail.resetURL must contain %s for the token")
+	}
+
+	if cfg.SoftDelete.Retention <= 0 {
+		problems = append(problems, "softDelete.retention must be positive")
+	}
+	if cfg.SoftDelete.PurgeInterval < 0 {
+		problems = append(problems, "softDelete.purgeInterval must not be negative")
+	}
+
+	switch cfg.Cascade.Posts {
+	case CascadeDelete, CascadeBlock:
+	case CascadeReassign:
+		if !primitive.IsValidObjectID(cfg.Cascade.ReassignTo) {
+			problems = append(problems, "cascade.reassignTo must be a user id when cascade.posts is reassign")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("cascade.posts must be delete, reassign or block, got %q", cfg.Cascade.Posts))
+	}
+
+	if _, err := logger.ParseLevel(cfg.Log.Level); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ConnectOptions derives the mongo dial policy from the configuration
+func (cfg *Config) ConnectOptions() ConnectOptions {
+	return ConnectOptions{
+		URI:            cfg.Mongo.URI,
+		Attempts:       cfg.Mongo.ConnectAttempts,
+		AttemptTimeout: time.Duration(cfg.Mongo.ConnectTimeout),
+		InitialBackoff: time.Duration(cfg.Mongo.InitialBackoff),
+		MaxBackoff:     time.Duration(cfg.Mongo.MaxBackoff),
+	}
+}
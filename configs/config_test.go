@@ -0,0 +1,95 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(cfg *Config)
+		problem string
+	}{
+		{"defaults", func(cfg *Config) {}, ""},
+		{"unknown env", func(cfg *Config) { cfg.Env = "staging" }, "env must be one of"},
+		{"zero initial backoff", func(cfg *Config) { cfg.Mongo.InitialBackoff = 0 }, "mongo.initialBackoff must be positive"},
+		{"negative initial backoff", func(cfg *Config) { cfg.Mongo.InitialBackoff = Duration(-time.Second) }, "mongo.initialBackoff must be positive"},
+		{"max backoff below the initial one", func(cfg *Config) { cfg.Mongo.MaxBackoff = Duration(100 * time.Millisecond) }, "mongo.maxBackoff must not be smaller"},
+		{"max page size below the default", func(cfg *Config) { cfg.Pagination.MaxPageSize = 5 }, "pagination.maxPageSize"},
+		{"short secret", func(cfg *Config) { cfg.Auth.JWTSecret = "short" }, "auth.jwtSecret must be at least 32 characters"},
+		{"unknown default action", func(cfg *Config) { cfg.Auth.DefaultActions = []string{"Fly"} }, "unknown actions Fly"},
+		{"reassign without a user", func(cfg *Config) { cfg.Cascade.Posts = CascadeReassign }, "cascade.reassignTo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults(EnvDev)
+			cfg.Mongo.URI = "mongodb://localhost:27017"
+			tt.change(&cfg)
+
+			err := cfg.Validate()
+			if tt.problem == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("Validate() = %v, want a problem containing %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	prodYAML := write("prod.yaml", "env: prod\nstorage: memory\n")
+	prodTOML := write("prod.toml", "env = \"prod\"\nstorage = \"memory\"\n")
+	testYAML := write("test.yaml", "env: test\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		appEnv string
+		env    string
+		err    string
+	}{
+		{"the file's env picks the profile", []string{"-config", testYAML}, "", EnvTest, ""},
+		{"the file's prod profile is validated", []string{"-config", prodYAML}, "", "", "storage memory is not allowed in prod"},
+		{"toml files too", []string{"-config", prodTOML}, "", "", "storage memory is not allowed in prod"},
+		{"APP_ENV wins over the file", []string{"-config", prodYAML}, EnvDev, EnvDev, ""},
+		{"the flag wins over APP_ENV", []string{"-config", testYAML, "-env", EnvDev, "-storage", StorageMemory}, EnvProd, EnvDev, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.appEnv)
+			t.Setenv("CONFIG_FILE", "")
+
+			cfg, err := Load(tt.args)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if cfg.Env != tt.env {
+				t.Errorf("env = %q, want %q", cfg.Env, tt.env)
+			}
+		})
+	}
+}
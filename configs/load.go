@@ -0,0 +1,221 @@
+package configs
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration from, in increasing priority, the profile
+// defaults, an optional YAML/TOML file, environment variables and CLI flags
+func Load(args []string) (*Config, error) {
+	// a missing .env file is fine, the variables may come from the real environment
+	_ = godotenv.Load()
+
+	fs := flag.NewFlagSet("restApi", flag.ContinueOnError)
+	var (
+		env             = fs.String("env", "", "environment profile: dev, test or prod (APP_ENV)")
+		configFile      = fs.String("config", "", "path to a YAML or TOML config file (CONFIG_FILE)")
+		storage         = fs.String("storage", "", "storage backend: mongo or memory (STORAGE)")
+		addr            = fs.String("addr", "", "listen address (SERVER_ADDR)")
+		mongoURI        = fs.String("mongo-uri", "", "mongo connection string (MONGOURI)")
+		database        = fs.String("db", "", "mongo database name (MONGO_DATABASE)")
+		logLevel        = fs.String("log-level", "", "debug, info, warn or error (LOG_LEVEL)")
+		pageSize        = fs.Int64("page-size", 0, "default page size (PAGINATION_DEFAULT_PAGE_SIZE)")
+		maxPageSize     = fs.Int64("max-page-size", 0, "maximum page size (PAGINATION_MAX_PAGE_SIZE)")
+		readTimeout     = fs.Duration("read-timeout", 0, "HTTP read timeout (SERVER_READ_TIMEOUT)")
+		writeTimeout    = fs.Duration("write-timeout", 0, "HTTP write timeout (SERVER_WRITE_TIMEOUT)")
+		idleTimeout     = fs.Duration("idle-timeout", 0, "HTTP idle timeout (SERVER_IDLE_TIMEOUT)")
+		shutdownTimeout = fs.Duration("shutdown-timeout", 0, "deadline for draining requests on shutdown (SERVER_SHUTDOWN_TIMEOUT)")
+		connectTimeout  = fs.Duration("connect-timeout", 0, "mongo connect timeout per attempt (MONGO_CONNECT_TIMEOUT)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// the profile picks the defaults, so the file's env has to be known before the file is applied
+	path := firstNonEmpty(*configFile, os.Getenv("CONFIG_FILE"))
+	profile := firstNonEmpty(*env, os.Getenv("APP_ENV"))
+	if profile == "" && path != "" {
+		var err error
+		if profile, err = fileEnv(path); err != nil {
+			return nil, err
+		}
+	}
+	profile = firstNonEmpty(profile, EnvDev)
+	cfg := Defaults(profile)
+
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+		// the profile chosen on the command line or environment wins over the file
+		cfg.Env = profile
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	// only flags that were passed explicitly override what was loaded so far
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "storage":
+			cfg.Storage = *storage
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "mongo-uri":
+			cfg.Mongo.URI = *mongoURI
+		case "db":
+			cfg.Mongo.Database = *database
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "page-size":
+			cfg.Pagination.DefaultPageSize = *pageSize
+		case "max-page-size":
+			cfg.Pagination.MaxPageSize = *maxPageSize
+		case "read-timeout":
+			cfg.Server.ReadTimeout = Duration(*readTimeout)
+		case "write-timeout":
+			cfg.Server.WriteTimeout = Duration(*writeTimeout)
+		case "idle-timeout":
+			cfg.Server.IdleTimeout = Duration(*idleTimeout)
+		case "shutdown-timeout":
+			cfg.Server.ShutdownTimeout = Duration(*shutdownTimeout)
+		case "connect-timeout":
+			cfg.Mongo.ConnectTimeout = Duration(*connectTimeout)
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	return decodeFile(path, cfg, true)
+}
+
+// fileEnv reads only the env of a config file, unknown fields are checked later by loadFile
+func fileEnv(path string) (string, error) {
+	var file struct {
+		Env string `yaml:"env" toml:"env"`
+	}
+	if err := decodeFile(path, &file, false); err != nil {
+		return "", err
+	}
+	return file.Env, nil
+}
+
+func decodeFile(path string, dst interface{}, strict bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(strict)
+		err = decoder.Decode(dst)
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		if strict {
+			decoder.DisallowUnknownFields()
+		}
+		err = decoder.Decode(dst)
+	default:
+		return fmt.Errorf("unsupported config file extension %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyEnv(cfg *Config) error {
+	stringVars := map[string]*string{
+		"STORAGE":             &cfg.Storage,
+		"SERVER_ADDR":         &cfg.Server.Addr,
+		"MONGOURI":            &cfg.Mongo.URI,
+		"MONGO_DATABASE":      &cfg.Mongo.Database,
+		"LOG_LEVEL":           &cfg.Log.Level,
+		"JWT_SECRET":          &cfg.Auth.JWTSecret,
+		"JWT_ISSUER":          &cfg.Auth.Issuer,
+		"MAIL_DRIVER":         &cfg.Mail.Driver,
+		"MAIL_DIR":            &cfg.Mail.Dir,
+		"MAIL_FROM":           &cfg.Mail.From,
+		"MAIL_RESET_URL":      &cfg.Mail.ResetURL,
+		"CASCADE_POSTS":       &cfg.Cascade.Posts,
+		"CASCADE_REASSIGN_TO": &cfg.Cascade.ReassignTo,
+	}
+	for key, target := range stringVars {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			*target = value
+		}
+	}
+
+	intVars := map[string]*int64{
+		"PAGINATION_DEFAULT_PAGE_SIZE": &cfg.Pagination.DefaultPageSize,
+		"PAGINATION_MAX_PAGE_SIZE":     &cfg.Pagination.MaxPageSize,
+	}
+	for key, target := range intVars {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*target = parsed
+		}
+	}
+
+	smallIntVars := map[string]*int{
+		"MONGO_CONNECT_ATTEMPTS": &cfg.Mongo.ConnectAttempts,
+		"PASSWORD_MIN_LENGTH":    &cfg.Auth.Password.MinLength,
+	}
+	for key, target := range smallIntVars {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*target = parsed
+		}
+	}
+
+	durationVars := map[string]*Duration{
+		"SERVER_READ_TIMEOUT":        &cfg.Server.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":       &cfg.Server.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":        &cfg.Server.IdleTimeout,
+		"SERVER_SHUTDOWN_TIMEOUT":    &cfg.Server.ShutdownTimeout,
+		"MONGO_CONNECT_TIMEOUT":      &cfg.Mongo.ConnectTimeout,
+		"SOFT_DELETE_RETENTION":      &cfg.SoftDelete.Retention,
+		"SOFT_DELETE_PURGE_INTERVAL": &cfg.SoftDelete.PurgeInterval,
+	}
+	for key, target := range durationVars {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			if err := target.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
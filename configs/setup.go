@@ -3,39 +3,81 @@ package configs
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/etg-dev/restApi/logger"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func ConnectDB() *mongo.Client {
-	client, err := mongo.NewClient(options.Client().ApplyURI(EnvMongoURI()))
-	if err != nil {
-		log.Fatal(err)
-	}
+// ConnectOptions controls how ConnectDB dials and retries the cluster
+type ConnectOptions struct {
+	URI            string
+	Attempts       int
+	AttemptTimeout time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
 
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+// ConnectDB dials mongo and pings it until it answers, backing off between attempts
+func ConnectDB(ctx context.Context, opts ConnectOptions) (*mongo.Client, error) {
+	if opts.URI == "" {
+		return nil, fmt.Errorf("mongo uri is empty")
+	}
+	if opts.Attempts < 1 {
+		opts.Attempts = 1
+	}
 
-	err = client.Connect(ctx)
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(opts.URI))
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("connecting to mongo: %w", err)
 	}
 
-	//ping the database
-	err = client.Ping(ctx, nil)
+	backoff := opts.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		//ping the database
+		err = ping(ctx, client, opts.AttemptTimeout)
+		if err == nil {
+			break
+		}
+		if attempt >= opts.Attempts {
+			break
+		}
+
+		logger.Warnf("mongo ping failed (attempt %d/%d): %v, retrying in %s", attempt, opts.Attempts, err, backoff)
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(backoff):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		backoff *= 2
+		if opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
 	if err != nil {
-		log.Fatal(err)
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("pinging mongo: %w", err)
 	}
-	fmt.Println("Connected to MongoDB")
-	return client
+
+	logger.Infof("Connected to MongoDB")
+	return client, nil
 }
 
-var DB *mongo.Client = ConnectDB()
+func ping(ctx context.Context, client *mongo.Client, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return client.Ping(ctx, nil)
+}
 
-// getting database collections
-func GetCollection(client *mongo.Client, collectionName string) *mongo.Collection {
-	collection := client.Database("blogDB").Collection(collectionName)
-	return collection
+// getting the application database
+func GetDatabase(client *mongo.Client, name string) *mongo.Database {
+	return client.Database(name)
 }
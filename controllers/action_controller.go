@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/audit"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/requests"
+	"github.com/etg-dev/restApi/responses"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// @descibe       List the actions granted directly to a user
+// @route         GET /users/:id/actions
+// @access        Self or Admin
+func GetUserActions(users repositories.UserRepository, actions repositories.ActionRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		user, ok := findUserParam(ctx, c, users)
+		if !ok {
+			return
+		}
+
+		userActions, err := actions.FindByUser(ctx, user.Id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			userActions = &models.Action{User: user.Id, Actions: []string{}}
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.UserResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": userActions}})
+	}
+}
+
+// @descibe       Replace every action granted to a user
+// @route         PUT /users/:id/actions
+// @access        Admin
+func ReplaceUserActions(users repositories.UserRepository, actions repositories.ActionRepository, recorder *audit.Recorder) gin.HandlerFunc {
+	return changeUserActions(users, actions, recorder, "actions.replace", true, actions.Replace)
+}
+
+// @descibe       Grant additional actions to a user
+// @route         PATCH /users/:id/actions
+// @access        Admin
+func AddUserActions(users repositories.UserRepository, actions repositories.ActionRepository, recorder *audit.Recorder) gin.HandlerFunc {
+	return changeUserActions(users, actions, recorder, "actions.add", false, actions.Add)
+}
+
+// @descibe       Revoke actions from a user, without a body the whole actions document is removed
+// @route         DELETE /users/:id/actions
+// @access        Admin
+func RemoveUserActions(users repositories.UserRepository, actions repositories.ActionRepository, tx repositories.Transactor, recorder *audit.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength != 0 {
+			changeUserActions(users, actions, recorder, "actions.remove", false, actions.Remove)(c)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		user, ok := findUserParam(ctx, c, users)
+		if !ok {
+			return
+		}
+
+		before, err := actions.FindByUser(ctx, user.Id)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			c.Error(err)
+			return
+		}
+
+		err = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := actions.DeleteByUser(ctx, user.Id); err != nil {
+				return err
+			}
+			return users.SetActionID(ctx, user.Id, primitive.NilObjectID)
+		})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		recorder.Record(ctx, models.AuditEntry{
+			Actor:      audit.Actor(c),
+			Event:      "actions.clear",
+			Resource:   repositories.UsersCollection,
+			ResourceId: user.Id,
+			Before:     actionList(before),
+			After:      []string{},
+		})
+
+		c.JSON(http.StatusOK, responses.UserResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": models.Action{User: user.Id, Actions: []string{}}}})
+	}
+}
+
+// changeUserActions validates the requested actions, applies them with apply and audits the change
+func changeUserActions(users repositories.UserRepository, actions repositories.ActionRepository, recorder *audit.Recorder, event string, allowEmpty bool,
+	apply func(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		user, ok := findUserParam(ctx, c, users)
+		if !ok {
+			return
+		}
+
+		var input requests.ActionsRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		requested := uniqueStrings(input.Actions)
+		if len(requested) == 0 && !allowEmpty {
+			c.Error(apperrors.Validation("Request body failed validation", map[string]string{"actions": "actions must contain at least 1 item"}))
+			return
+		}
+
+		before, err := actions.FindByUser(ctx, user.Id)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			c.Error(err)
+			return
+		}
+
+		after, err := apply(ctx, user.Id, requested)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("There is no action for that user"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		// keep the user's actionId pointing at the document, upserts may have just created it
+		if user.ActionId != after.Id {
+			if err = users.SetActionID(ctx, user.Id, after.Id); err != nil {
+				c.Error(err)
+				return
+			}
+		}
+
+		recorder.Record(ctx, models.AuditEntry{
+			Actor:      audit.Actor(c),
+			Event:      event,
+			Resource:   repositories.UsersCollection,
+			ResourceId: user.Id,
+			Before:     actionList(before),
+			After:      actionList(after),
+		})
+
+		c.JSON(http.StatusOK, responses.UserResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": after}})
+	}
+}
+
+// findUserParam loads the :id user and writes the error response when it can not
+func findUserParam(ctx context.Context, c *gin.Context, users repositories.UserRepository) (*models.User, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.InvalidID("id", err))
+		return nil, false
+	}
+
+	user, err := users.FindByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.Error(apperrors.NotFound("User not found with that id"))
+		return nil, false
+	}
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return user, true
+}
+
+func actionList(action *models.Action) []string {
+	if action == nil || action.Actions == nil {
+		return []string{}
+	}
+	return action.Actions
+}
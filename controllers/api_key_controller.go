@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/audit"
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/requests"
+	"github.com/etg-dev/restApi/responses"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// @descibe       Create a named API key for the user, the key is only shown in this response
+// @route         POST /users/:id/keys
+// @access        Self or Admin
+func CreateAPIKey(users repositories.UserRepository, keys repositories.APIKeyRepository, recorder *audit.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		user, ok := findUserParam(ctx, c, users)
+		if !ok {
+			return
+		}
+
+		var input requests.APIKeyRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+		scopes := uniqueStrings(input.Scopes)
+
+		secret, prefix, hash, err := auth.NewAPIKey()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		key := models.APIKey{
+			User:      user.Id,
+			Name:      strings.TrimSpace(input.Name),
+			Prefix:    prefix,
+			KeyHash:   hash,
+			Scopes:    scopes,
+			CreatedAt: time.Now().UTC(),
+		}
+		if err = keys.Create(ctx, &key); err != nil {
+			c.Error(err)
+			return
+		}
+
+		recorder.Record(ctx, models.AuditEntry{
+			Actor:      audit.Actor(c),
+			Event:      "apikey.create",
+			Resource:   repositories.APIKeysCollection,
+			ResourceId: key.Id,
+			After:      scopes,
+		})
+
+		c.JSON(http.StatusCreated, responses.APIKeyResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": key, "key": secret}})
+	}
+}
+
+// @descibe       List the API keys of the user, revoked keys included
+// @route         GET /users/:id/keys
+// @access        Self or Admin
+func GetAPIKeys(users repositories.UserRepository, keys repositories.APIKeyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		user, ok := findUserParam(ctx, c, users)
+		if !ok {
+			return
+		}
+
+		found, err := keys.FindByUser(ctx, user.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if found == nil {
+			found = []models.APIKey{}
+		}
+
+		c.JSON(http.StatusOK, responses.APIKeyResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": found}})
+	}
+}
+
+// @descibe       Revoke an API key of the user
+// @route         DELETE /users/:id/keys/:keyId
+// @access        Self or Admin
+func RevokeAPIKey(users repositories.UserRepository, keys repositories.APIKeyRepository, recorder *audit.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		user, ok := findUserParam(ctx, c, users)
+		if !ok {
+			return
+		}
+
+		keyId, err := primitive.ObjectIDFromHex(c.Param("keyId"))
+		if err != nil {
+			c.Error(apperrors.InvalidID("keyId", err))
+			return
+		}
+
+		err = keys.Revoke(ctx, user.Id, keyId, time.Now().UTC())
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Active key not found with that id"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		recorder.Record(ctx, models.AuditEntry{
+			Actor:      audit.Actor(c),
+			Event:      "apikey.revoke",
+			Resource:   repositories.APIKeysCollection,
+			ResourceId: keyId,
+		})
+
+		c.JSON(http.StatusOK, responses.APIKeyResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "key revoked"}})
+	}
+}
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/logger"
+	"github.com/etg-dev/restApi/mailer"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/requests"
+	"github.com/etg-dev/restApi/responses"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// @descibe       Exchange email and password for an access and refresh token
+// @route         POST /auth/login
+// @access        Public
+func Login(users repositories.UserRepository, tokens *auth.TokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var input requests.LoginRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		user, err := users.FindByEmail(ctx, input.Email)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			c.Error(err)
+			return
+		}
+		// an unknown email is checked against no hash, which costs as much as a wrong password
+		hash := ""
+		if user != nil {
+			hash = user.PasswordHash
+		}
+		if auth.CheckPassword(hash, input.Password) != nil {
+			c.Error(apperrors.Unauthorized(auth.ErrInvalidCredentials.Error()))
+			return
+		}
+
+		pair, err := tokens.Issue(user.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.AuthResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": pair}})
+	}
+}
+
+// @descibe       Rotate a refresh token into a new token pair
+// @route         POST /auth/refresh
+// @access        Public
+func Refresh(users repositories.UserRepository, tokens *auth.TokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var input requests.RefreshRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		claims, err := tokens.Verify(ctx, input.RefreshToken, auth.RefreshToken)
+		if errors.Is(err, auth.ErrInvalidToken) {
+			c.Error(apperrors.Unauthorized("Invalid or expired refresh token"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		userId, _ := claims.UserID()
+		if _, err = users.FindByID(ctx, userId); err != nil {
+			c.Error(apperrors.Unauthorized("User not found with that id"))
+			return
+		}
+
+		// refresh tokens are single use, the old one is revoked before the new pair goes out
+		if err = tokens.Revoke(ctx, claims); err != nil {
+			c.Error(err)
+			return
+		}
+
+		pair, err := tokens.Issue(userId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.AuthResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": pair}})
+	}
+}
+
+// @descibe       Revoke the current access token and optionally its refresh token
+// @route         POST /auth/logout
+// @access        Authenticated
+func Logout(tokens *auth.TokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		claims, ok := c.Value("claims").(*auth.Claims)
+		if !ok {
+			c.Error(apperrors.BadRequest("Only bearer tokens can log out, API keys are revoked instead"))
+			return
+		}
+
+		var input requests.LogoutRequest
+		if c.Request.ContentLength != 0 {
+			if err := requests.Bind(c, &input); err != nil {
+				c.Error(err)
+				return
+			}
+		}
+
+		if err := tokens.Revoke(ctx, claims); err != nil {
+			c.Error(err)
+			return
+		}
+
+		if input.RefreshToken != "" {
+			refreshClaims, err := tokens.Verify(ctx, input.RefreshToken, auth.RefreshToken)
+			if err == nil && refreshClaims.Subject == claims.Subject {
+				err = tokens.Revoke(ctx, refreshClaims)
+			}
+			if err != nil && !errors.Is(err, auth.ErrInvalidToken) {
+				c.Error(err)
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, responses.AuthResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "logged out"}})
+	}
+}
+
+// @descibe       Register a new user with a password and the default actions
+// @route         POST /auth/register
+// @access        Public
+func Register(users repositories.UserRepository, actions repositories.ActionRepository, tx repositories.Transactor, cfg configs.AuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var input requests.RegisterRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+		if problems := auth.CheckPolicy(cfg.Password, input.Password); len(problems) > 0 {
+			c.Error(apperrors.Validation("Weak password", map[string]string{"password": auth.PolicyMessage(problems)}))
+			return
+		}
+
+		hash, err := auth.HashPassword(input.Password)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		newUser := models.User{
+			Name:         input.Name,
+			Email:        input.Email,
+			PasswordHash: hash,
+		}
+		createdUser, err := createUser(ctx, users, actions, tx, &newUser, cfg.DefaultActions)
+		if errors.Is(err, repositories.ErrDuplicate) {
+			c.Error(apperrors.Conflict("Email already registered"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.AuthResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": createdUser}})
+	}
+}
+
+// @descibe       Change the password of the current user, the old password is required
+// @route         PUT /auth/password
+// @access        Authenticated
+func ChangePassword(users repositories.UserRepository, tokens *auth.TokenService, keys repositories.APIKeyRepository, policy configs.PasswordConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var input requests.ChangePasswordRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		user := c.MustGet("user").(*models.User)
+		if auth.CheckPassword(user.PasswordHash, input.OldPassword) != nil {
+			c.Error(apperrors.Unauthorized("Old password does not match"))
+			return
+		}
+		if problems := auth.CheckPolicy(policy, input.NewPassword); len(problems) > 0 {
+			c.Error(apperrors.Validation("Weak password", map[string]string{"newPassword": auth.PolicyMessage(problems)}))
+			return
+		}
+
+		hash, err := auth.HashPassword(input.NewPassword)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if err = users.SetPasswordHash(ctx, user.Id, hash); err != nil {
+			c.Error(err)
+			return
+		}
+		// every session, this one included, has to log in again with the new password
+		if err = revokeCredentials(ctx, tokens, keys, user.Id); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.AuthResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "password changed"}})
+	}
+}
+
+// @descibe       Mail a single use reset link to the owner of the email
+// @route         POST /auth/password/forgot
+// @access        Public
+func ForgotPassword(users repositories.UserRepository, resets repositories.PasswordResetRepository, mail mailer.Mailer, cfg *configs.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var input requests.ForgotPasswordRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		// the answer is the same whether the email is known or not so accounts can not be probed
+		accepted := responses.AuthResponse{Status: http.StatusAccepted, Message: "success", Data: map[string]interface{}{"data": "if the email is registered a reset link is on its way"}}
+
+		user, err := users.FindByEmail(ctx, input.Email)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusAccepted, accepted)
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		token, hash, err := auth.NewResetToken()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		reset := models.PasswordReset{
+			User:      user.Id,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(time.Duration(cfg.Auth.Password.ResetTokenTTL)),
+		}
+		if err = resets.Create(ctx, &reset); err != nil {
+			c.Error(err)
+			return
+		}
+
+		err = mail.Send(ctx, mailer.Message{
+			From:    cfg.Mail.From,
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body:    fmt.Sprintf("Open the link below to choose a new password, it is valid for %s.\n\n%s", time.Duration(cfg.Auth.Password.ResetTokenTTL), fmt.Sprintf(cfg.Mail.ResetURL, token)),
+		})
+		if err != nil {
+			logger.Errorf("sending password reset mail to %s: %v", user.Email, err)
+		}
+
+		c.JSON(http.StatusAccepted, accepted)
+	}
+}
+
+// @descibe       Set a new password with a reset token, the token can only be used once
+// @route         POST /auth/password/reset
+// @access        Public
+func ResetPassword(users repositories.UserRepository, resets repositories.PasswordResetRepository, tokens *auth.TokenService, keys repositories.APIKeyRepository, policy configs.PasswordConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var input requests.ResetPasswordRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		// the policy is checked first so a weak password does not burn the token
+		if problems := auth.CheckPolicy(policy, input.Password); len(problems) > 0 {
+			c.Error(apperrors.Validation("Weak password", map[string]string{"password": auth.PolicyMessage(problems)}))
+			return
+		}
+
+		hash, err := auth.HashPassword(input.Password)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		reset, err := resets.Consume(ctx, auth.HashResetToken(input.Token), time.Now())
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.BadRequest("Invalid or expired reset token"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		err = users.SetPasswordHash(ctx, reset.User, hash)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.BadRequest("Invalid or expired reset token"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		// a reset usually means the account was compromised, so sessions opened with the old password end
+		if err = revokeCredentials(ctx, tokens, keys, reset.User); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.AuthResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": "password reset"}})
+	}
+}
+
+// revokeCredentials ends everything that was issued while the old password was valid: the
+// user's tokens and API keys, since keys created by whoever knew the password outlive it
+func revokeCredentials(ctx context.Context, tokens *auth.TokenService, keys repositories.APIKeyRepository, userId primitive.ObjectID) error {
+	if err := tokens.RevokeUser(ctx, userId); err != nil {
+		return err
+	}
+	return keys.RevokeByUser(ctx, userId, time.Now().UTC())
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/requests"
+	"github.com/etg-dev/restApi/responses"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// defaultCommentDepth and maxCommentDepth bound how many levels of a thread one request returns
+	defaultCommentDepth = 3
+	maxCommentDepth     = 10
+	// maxCommentNesting bounds how deep replies may be nested at all
+	maxCommentNesting = 32
+)
+
+// @descibe       Get the comment threads of a post, ?depth= limits the levels and ?parent= starts below a comment
+// @route         GET /posts/:postId/comments
+// @access        Authenticated
+func GetComments(posts repositories.PostRepository, comments repositories.CommentRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		post, ok := findPostParam(ctx, c, posts)
+		if !ok {
+			return
+		}
+
+		depth := defaultCommentDepth
+		if param, ok := c.GetQuery("depth"); ok {
+			var err error
+			depth, err = strconv.Atoi(param)
+			if err != nil || depth < 1 || depth > maxCommentDepth {
+				c.Error(apperrors.BadRequest(fmt.Sprintf("depth must be a number from 1 to %d", maxCommentDepth)))
+				return
+			}
+		}
+
+		// threads are paged by their top level comments, which are read oldest first
+		if _, cursorMode := c.Get("after"); cursorMode {
+			c.Error(apperrors.BadRequest("Comments can only be paged with page"))
+			return
+		}
+		page := c.GetInt64("page")
+		pageSize := c.GetInt64("pageSize")
+
+		var root *models.Comment
+		if param := c.Query("parent"); param != "" {
+			parentId, err := primitive.ObjectIDFromHex(param)
+			if err != nil {
+				c.Error(apperrors.InvalidID("parent", err))
+				return
+			}
+			root, err = comments.FindByID(ctx, parentId)
+			if err == nil && root.Post != post.Id {
+				err = repositories.ErrNotFound
+			}
+			if errors.Is(err, repositories.ErrNotFound) {
+				c.Error(apperrors.NotFound("Comment not found on this post").Wrap(err))
+				return
+			}
+			if err != nil {
+				c.Error(err)
+				return
+			}
+		}
+
+		roots, total, err := comments.FindRoots(ctx, post.Id, root, (page-1)*pageSize, pageSize)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		// the roots are the first level shown, one level more than shown is read below them
+		// so the deepest comments can tell their reply count
+		replies, err := comments.FindReplies(ctx, roots, depth)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommentResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]interface{}{"data": buildThreads(append(roots, replies...), root, depth)},
+			Meta:    pageMeta(c, page, pageSize, total),
+		})
+	}
+}
+
+// buildThreads nests the comments below root, or below the post when root is nil, depth levels deep
+func buildThreads(comments []models.Comment, root *models.Comment, depth int) []models.CommentThread {
+	// comments on the post itself have no parent and are filed under the zero id
+	replies := map[primitive.ObjectID][]models.Comment{}
+	for _, comment := range comments {
+		var parent primitive.ObjectID
+		if comment.Parent != nil {
+			parent = *comment.Parent
+		}
+		replies[parent] = append(replies[parent], comment)
+	}
+
+	var build func(parent primitive.ObjectID, levels int) []models.CommentThread
+	build = func(parent primitive.ObjectID, levels int) []models.CommentThread {
+		threads := []models.CommentThread{}
+		for _, comment := range replies[parent] {
+			thread := models.CommentThread{Comment: comment, ReplyCount: len(replies[comment.Id])}
+			if levels > 1 {
+				thread.Replies = build(comment.Id, levels-1)
+			}
+			threads = append(threads, thread)
+		}
+		return threads
+	}
+
+	var top primitive.ObjectID
+	if root != nil {
+		top = root.Id
+	}
+	return build(top, depth)
+}
+
+// @descibe       Comment on a post or reply to a comment
+// @route         POST /posts/:postId/comments
+// @access        Authenticated
+func CreateComment(posts repositories.PostRepository, comments repositories.CommentRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var input requests.CreateCommentRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		post, ok := findPostParam(ctx, c, posts)
+		if !ok {
+			return
+		}
+
+		comment := models.Comment{
+			Post: post.Id,
+			User: c.MustGet("userId").(primitive.ObjectID),
+			Body: input.Body,
+		}
+
+		if input.Parent != "" {
+			parentId, err := primitive.ObjectIDFromHex(input.Parent)
+			if err != nil {
+				c.Error(apperrors.InvalidID("parent", err))
+				return
+			}
+			parent, err := comments.FindByID(ctx, parentId)
+			if errors.Is(err, repositories.ErrNotFound) || err == nil && parent.Post != post.Id {
+				c.Error(apperrors.Validation("Invalid reply", map[string]string{"parent": "must be a comment on this post"}))
+				return
+			}
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			if len(parent.Ancestors)+1 >= maxCommentNesting {
+				c.Error(apperrors.Validation("Invalid reply", map[string]string{"parent": fmt.Sprintf("replies can not be nested more than %d levels deep", maxCommentNesting)}))
+				return
+			}
+			comment.Parent = &parent.Id
+			comment.Ancestors = append(append([]primitive.ObjectID(nil), parent.Ancestors...), parent.Id)
+		}
+
+		if err := comments.Create(ctx, &comment); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.CommentResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": comment}})
+	}
+}
+
+// @descibe       Edit a comment
+// @route         PATCH /comments/:id
+// @access        Author or Admin
+func UpdateComment(comments repositories.CommentRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		comment := c.MustGet("comment").(*models.Comment)
+
+		var input requests.UpdateCommentRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		updated, err := comments.Update(ctx, comment.Id, input.Body)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Comment not found").Wrap(err))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommentResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updated}})
+	}
+}
+
+// @descibe       Delete a comment together with the replies below it
+// @route         DELETE /comments/:id
+// @access        Author or Admin
+func DeleteComment(comments repositories.CommentRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		comment := c.MustGet("comment").(*models.Comment)
+
+		err := comments.Delete(ctx, comment.Id, time.Now().UTC())
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Comment not found").Wrap(err))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.CommentResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": map[string]interface{}{"DeletedCount": 1}}})
+	}
+}
+
+// findPostParam loads the live :postId post, writing the error response when there is none
+func findPostParam(ctx context.Context, c *gin.Context, posts repositories.PostRepository) (*models.Post, bool) {
+	postId, err := primitive.ObjectIDFromHex(c.Param("postId"))
+	if err != nil {
+		c.Error(apperrors.InvalidID("postId", err))
+		return nil, false
+	}
+
+	post, err := posts.FindByID(ctx, postId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.Error(apperrors.NotFound("Post not found with that id").Wrap(err))
+		return nil, false
+	}
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return post, true
+}
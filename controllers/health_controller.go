@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/etg-dev/restApi/health"
+	"github.com/gin-gonic/gin"
+)
+
+// @descibe       Process liveness probe
+// @route         GET /healthz
+// @access        Public
+func Liveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+	}
+}
+
+// @descibe       Readiness probe with per dependency status
+// @route         GET /readyz
+// @access        Public
+func Readiness(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		report := checker.Check(ctx)
+		if report.Status != health.StatusUp {
+			c.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
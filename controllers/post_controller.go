@@ -2,59 +2,41 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/logger"
 	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/query"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/requests"
 	"github.com/etg-dev/restApi/responses"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var postCollection *mongo.Collection = configs.GetCollection(configs.DB, "posts")
-
 // @descibe       Create new post
-// @route         POST /posts/:userId
-// @access        Public
-func CreatePost() gin.HandlerFunc {
+// @route         POST /posts
+// @access        Authenticated
+func CreatePost(posts repositories.PostRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		var input models.Post
-		if err := c.BindJSON(&input); err != nil {
-			c.JSON(http.StatusInternalServerError, responses.PostResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
-		}
-
-		if input.Title == "" || input.Content == "" {
-			c.JSON(http.StatusBadRequest, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": "title and content required"}})
-			return
-		}
-
-		userId, err := primitive.ObjectIDFromHex(c.Param("userId"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, responses.UserResponse{Status: http.StatusInternalServerError, Message: "Id required", Data: map[string]interface{}{"data": err.Error()}})
-			return
-		}
-
-		// Check if the user exists
-		userFilter := bson.M{"_id": userId}
-		user, err := postCollection.Find(ctx, userFilter)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+		var input requests.CreatePostRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
 			return
 		}
 
-		if user == nil {
-			c.JSON(http.StatusBadRequest, responses.UserResponse{Status: http.StatusInternalServerError, Message: "User not found", Data: map[string]interface{}{"data": err.Error()}})
-			return
-		}
+		// the author is the authenticated user, ValidateUserID already checked it exists
+		userId := c.MustGet("userId").(primitive.ObjectID)
 
 		post := models.Post{
 			Title:   input.Title,
@@ -62,24 +44,24 @@ func CreatePost() gin.HandlerFunc {
 			User:    userId,
 		}
 
-		result, err := postCollection.InsertOne(ctx, post)
+		err := posts.Create(ctx, &post)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.UserResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": result}})
+		c.JSON(http.StatusCreated, responses.PostResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": map[string]interface{}{"InsertedID": post.Id}}})
 	}
 }
 
 // @descibe       Get all posts
-// @route         GET /posts/:userId
-// @access        Public
+// @route         GET /posts
+// @access        Authenticated
 // func GetPosts() gin.HandlerFunc {
 // 	return func(c *gin.Context) {
 
 // 		var posts []models.Post
-// 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 // 		defer cancel()
 
 // 		page, ok := c.Get("page")
@@ -131,108 +113,416 @@ func CreatePost() gin.HandlerFunc {
 // 	}
 // }
 
-func GetPosts() gin.HandlerFunc {
+func GetPosts(posts repositories.PostRepository, comments repositories.CommentRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		page, ok := c.Get("page")
-		if !ok {
-			page = 1
+		page := c.GetInt64("page")
+		pageSize := c.GetInt64("pageSize")
+		after, cursorMode := c.Get("after")
+
+		q := c.MustGet("query").(*query.Query)
+		selection := c.MustGet("selection").(*query.Selection)
+
+		opts := repositories.ListOptions{
+			Skip:       (page - 1) * pageSize,
+			Limit:      pageSize,
+			Projection: postProjection(selection),
+			Filter:     q.Filter,
+			Sort:       q.Sort,
+			Count:      !cursorMode,
+			Cursor:     cursorMode,
+		}
+		if cursorMode {
+			opts.After = after.(string)
 		}
 
-		pageSize, ok := c.Get("pageSize")
-		if !ok {
-			pageSize = 10
+		result, err := posts.List(ctx, opts)
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			c.Error(apperrors.BadRequest("after is not a valid cursor for this sort"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		items, err := listedPosts(ctx, comments, selection, result.Items)
+		if err != nil {
+			c.Error(err)
+			return
 		}
 
-		fieldsParam := c.Query("select")
-		projection := bson.M{}
-		if fieldsParam != "" {
-			fields := strings.Split(fieldsParam, ",")
-			for _, field := range fields {
-				projection[field] = 1
-			}
+		var meta *responses.Meta
+		if cursorMode {
+			meta = cursorMeta(c, pageSize, result.Next)
 		} else {
-			projection = bson.M{"id": 0}
+			meta = pageMeta(c, page, pageSize, result.Total)
+		}
+
+		c.JSON(http.StatusOK, responses.PostResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]interface{}{"data": items},
+			Meta:    meta,
+		})
+	}
+}
+
+// pageMeta describes an offset page and links its neighbours in the Link header
+func pageMeta(c *gin.Context, page, pageSize, total int64) *responses.Meta {
+	totalPages := (total + pageSize - 1) / pageSize
+
+	links := []string{pageLink(c, "first", map[string]string{"page": "1"})}
+	if page > 1 {
+		links = append(links, pageLink(c, "prev", map[string]string{"page": strconv.FormatInt(page-1, 10)}))
+	}
+	if page < totalPages {
+		links = append(links, pageLink(c, "next", map[string]string{"page": strconv.FormatInt(page+1, 10)}))
+	}
+	if totalPages > 0 {
+		links = append(links, pageLink(c, "last", map[string]string{"page": strconv.FormatInt(totalPages, 10)}))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+
+	return &responses.Meta{Page: page, PageSize: pageSize, Total: &total, TotalPages: &totalPages}
+}
+
+// cursorMeta describes a cursor page, only the first and the next page can be linked
+func cursorMeta(c *gin.Context, pageSize int64, next string) *responses.Meta {
+	links := []string{pageLink(c, "first", map[string]string{"after": ""})}
+	if next != "" {
+		links = append(links, pageLink(c, "next", map[string]string{"after": next}))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+
+	return &responses.Meta{PageSize: pageSize, Next: next}
+}
+
+// pageLink formats one RFC 5988 link to the current URL with the given query parameters replaced
+func pageLink(c *gin.Context, rel string, params map[string]string) string {
+	target := *c.Request.URL
+	query := target.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	target.RawQuery = query.Encode()
+	return fmt.Sprintf("<%s>; rel=\"%s\"", target.RequestURI(), rel)
+}
+
+// maxSearchLength bounds the search text so one request can not ask for hundreds of terms
+const maxSearchLength = 256
+
+// @descibe       Search posts by title and content, best match first
+// @route         GET /posts/search?q=
+// @access        Authenticated
+func SearchPosts(posts repositories.PostRepository, comments repositories.CommentRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		text := strings.TrimSpace(c.Query("q"))
+		problem := ""
+		switch {
+		case text == "":
+			problem = "is required"
+		case len(text) > maxSearchLength:
+			problem = fmt.Sprintf("must be at most %d characters", maxSearchLength)
+		}
+		if problem != "" {
+			err := apperrors.BadRequest("Invalid search")
+			err.Fields = map[string]string{"q": problem}
+			c.Error(err)
+			return
 		}
 
-		pipeline := []bson.M{
-			{"$match": bson.M{}},
-			{"$sort": bson.M{"created_at": -1}},
-			{"$skip": page.(int64) * pageSize.(int64)},
-			{"$limit": pageSize.(int64)},
-			{"$project": projection},
+		// results are ordered by relevance, which a cursor can not continue from
+		if _, cursorMode := c.Get("after"); cursorMode {
+			c.Error(apperrors.BadRequest("Search results can only be paged with page"))
+			return
 		}
 
-		cursor, err := postCollection.Aggregate(ctx, pipeline)
+		page := c.GetInt64("page")
+		pageSize := c.GetInt64("pageSize")
+		selection := c.MustGet("selection").(*query.Selection)
+
+		result, err := posts.Search(ctx, repositories.SearchOptions{
+			Text:       text,
+			Language:   c.Query("language"),
+			Skip:       (page - 1) * pageSize,
+			Limit:      pageSize,
+			Projection: postProjection(selection),
+			Count:      true,
+		})
+		if errors.Is(err, repositories.ErrUnsupportedLanguage) {
+			err := apperrors.BadRequest("Invalid search")
+			err.Fields = map[string]string{"language": fmt.Sprintf("%q is not a supported text search language", c.Query("language"))}
+			c.Error(err)
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{
-				Status:  http.StatusInternalServerError,
-				Message: "error",
-				Data:    map[string]interface{}{"data": err.Error()},
-			})
+			c.Error(err)
 			return
 		}
-		defer cursor.Close(ctx)
-
-		var posts []bson.M
-		if err = cursor.All(ctx, &posts); err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{
-				Status:  http.StatusInternalServerError,
-				Message: "error",
-				Data:    map[string]interface{}{"data": err.Error()},
-			})
+		// the score and highlights are not part of the post model and are copied as they are
+		items, err := listedPosts(ctx, comments, selection, result.Items, "score", "highlights")
+		if err != nil {
+			c.Error(err)
 			return
 		}
 
-		c.JSON(http.StatusOK, responses.UserResponse{
+		c.JSON(http.StatusOK, responses.PostResponse{
 			Status:  http.StatusOK,
 			Message: "success",
-			Data:    map[string]interface{}{"data": posts},
+			Data:    map[string]interface{}{"data": items},
+			Meta:    pageMeta(c, page, pageSize, result.Total),
 		})
 	}
 }
 
-// @descibe       Get all user posts
+// @descibe       Get a page of a user's posts
 // @route         GET /posts/user/:userId
 // @access        Public
-func GetUsersPosts() gin.HandlerFunc {
+func GetUsersPosts(posts repositories.PostRepository, comments repositories.CommentRepository) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
-		var posts []models.Post
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
 		userId, err := primitive.ObjectIDFromHex(c.Param("userId"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, responses.UserResponse{Status: http.StatusInternalServerError, Message: "User id required", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(apperrors.InvalidID("userId", err))
+			return
+		}
+
+		logger.Debugf("posts requested for user %s", userId.Hex())
+
+		page := c.GetInt64("page")
+		pageSize := c.GetInt64("pageSize")
+		after, cursorMode := c.Get("after")
+
+		selection := c.MustGet("selection").(*query.Selection)
+
+		opts := repositories.ListOptions{
+			Skip:       (page - 1) * pageSize,
+			Limit:      pageSize,
+			Projection: postProjection(selection),
+			Filter:     bson.M{"user": userId},
+			Count:      !cursorMode,
+			Cursor:     cursorMode,
+		}
+		if cursorMode {
+			opts.After = after.(string)
+		}
+
+		result, err := posts.List(ctx, opts)
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			c.Error(apperrors.BadRequest("after is not a valid cursor for this sort"))
 			return
 		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			return
 		}
-
-		fmt.Println(userId)
-
-		filter := bson.M{"user": userId}
-		cur, err := postCollection.Find(ctx, filter)
+		items, err := listedPosts(ctx, comments, selection, result.Items)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			return
 		}
 
-		for cur.Next(ctx) {
-			var post models.Post
-			err := cur.Decode(&post)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var meta *responses.Meta
+		if cursorMode {
+			meta = cursorMeta(c, pageSize, result.Next)
+		} else {
+			meta = pageMeta(c, page, pageSize, result.Total)
+		}
+
+		c.JSON(http.StatusOK, responses.PostResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]interface{}{"data": items},
+			Meta:    meta,
+		})
+	}
+}
+
+// @descibe       Replace or partially update a post
+// @route         PUT|PATCH /posts/:postId
+// @access        Owner or Admin
+func UpdatePost(posts repositories.PostRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		post := c.MustGet("post").(*models.Post)
+
+		// PUT replaces the post so both fields are required, PATCH only needs what changes
+		var update repositories.PostUpdate
+		if c.Request.Method == http.MethodPut {
+			var input requests.CreatePostRequest
+			if err := requests.Bind(c, &input); err != nil {
+				c.Error(err)
+				return
+			}
+			update = repositories.PostUpdate{Title: &input.Title, Content: &input.Content}
+		} else {
+			var input requests.UpdatePostRequest
+			if err := requests.Bind(c, &input); err != nil {
+				c.Error(err)
 				return
 			}
-			posts = append(posts, post)
+			update = repositories.PostUpdate{Title: input.Title, Content: input.Content}
+		}
+
+		updatedPost, err := posts.Update(ctx, post.Id, update)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.PostResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedPost}})
+	}
+}
+
+// @descibe       Delete a post
+// @route         DELETE /posts/:postId
+// @access        Owner or Admin
+func DeletePost(posts repositories.PostRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		post := c.MustGet("post").(*models.Post)
+
+		err := posts.Delete(ctx, post.Id, time.Now().UTC())
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Post not found").Wrap(err))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
 		}
 
-		c.JSON(http.StatusOK, responses.UserResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": posts}})
+		c.JSON(http.StatusOK, responses.PostResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": map[string]interface{}{"DeletedCount": 1}}})
 	}
 }
+
+// @descibe       Get single post, ?expand=user embeds the author
+// @route         GET /posts/item/:postId
+// @access        Public
+func GetPost(posts repositories.PostRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		postId, err := primitive.ObjectIDFromHex(c.Param("postId"))
+		if err != nil {
+			c.Error(apperrors.InvalidID("postId", err))
+			return
+		}
+
+		var post interface{}
+		switch expand := c.Query("expand"); expand {
+		case "":
+			post, err = posts.FindByID(ctx, postId)
+		case "user":
+			post, err = posts.FindByIDWithAuthor(ctx, postId)
+		default:
+			c.Error(apperrors.BadRequest(fmt.Sprintf("Can not expand %q", expand)))
+			return
+		}
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Post not found").Wrap(err))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		selection := c.MustGet("selection").(*query.Selection)
+
+		c.JSON(http.StatusOK, responses.PostResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": selection.Apply(post)}})
+	}
+}
+
+// @descibe       Restore a soft deleted post
+// @route         POST /posts/:postId/restore
+// @access        Admin
+func RestorePost(posts repositories.PostRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		postId, err := primitive.ObjectIDFromHex(c.Param("postId"))
+		if err != nil {
+			c.Error(apperrors.InvalidID("postId", err))
+			return
+		}
+
+		err = posts.Restore(ctx, postId)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("No deleted post found with that id").Wrap(err))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		post, err := posts.FindByID(ctx, postId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.PostResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": post}})
+	}
+}
+
+// listedPosts renders listed documents through the post model and the selection, so they
+// have the keys of a single post, and adds the comment count of every post. The extra
+// document keys are kept next to the post fields.
+func listedPosts(ctx context.Context, comments repositories.CommentRepository, selection *query.Selection, docs []bson.M, extra ...string) ([]map[string]interface{}, error) {
+	if selection == nil {
+		selection = query.PostFields.All()
+	}
+
+	posts := make([]models.Post, len(docs))
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		if err = bson.Unmarshal(data, &posts[i]); err != nil {
+			return nil, err
+		}
+		ids[i] = posts[i].Id
+	}
+
+	counts, err := comments.CountByPosts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, len(posts))
+	for i, post := range posts {
+		items[i] = selection.Apply(post).(map[string]interface{})
+		items[i]["commentCount"] = counts[post.Id]
+		for _, key := range extra {
+			if value, ok := docs[i][key]; ok {
+				items[i][key] = value
+			}
+		}
+	}
+	return items, nil
+}
+
+// postProjection always reads _id, listedPosts looks the comment counts up by it and drops
+// it again through the selection when the client excluded it
+func postProjection(selection *query.Selection) bson.M {
+	projection := selection.Projection()
+	delete(projection, "_id")
+	return projection
+}
@@ -0,0 +1,209 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/controllers"
+	"github.com/etg-dev/restApi/middleware"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/query"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// serve runs one request through handlers registered on route, as the given user when set
+func serve(method, route, target, body string, userId primitive.ObjectID, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(), func(c *gin.Context) {
+		if !userId.IsZero() {
+			c.Set("userId", userId)
+		}
+	})
+	router.Handle(method, route, handlers...)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCreatePost(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"valid", `{"title":"title","content":"content"}`, http.StatusCreated},
+		{"missing title", `{"content":"content"}`, http.StatusUnprocessableEntity},
+		{"blank content", `{"title":"title","content":"  "}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repositories.NewMemoryRepositories()
+			author := primitive.NewObjectID()
+
+			rec := serve(http.MethodPost, "/posts", "/posts", tt.body, author, controllers.CreatePost(repos.Posts))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body)
+			}
+
+			// the author is taken from the context, never from the body
+			count, err := repos.Posts.CountByUser(context.Background(), author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want int64
+			if tt.status == http.StatusCreated {
+				want = 1
+			}
+			if count != want {
+				t.Errorf("the author has %d posts, want %d", count, want)
+			}
+		})
+	}
+}
+
+func TestGetPost(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories()
+	author := &models.User{Name: "author", Email: "author@example.com"}
+	if err := repos.Users.Create(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+	live := &models.Post{Title: "live", Content: "content", User: author.Id}
+	deleted := &models.Post{Title: "deleted", Content: "content", User: author.Id}
+	for _, post := range []*models.Post{live, deleted} {
+		if err := repos.Posts.Create(ctx, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.Posts.Delete(ctx, deleted.Id, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		status   int
+		contains string
+	}{
+		{"found", "/posts/" + live.Id.Hex(), http.StatusOK, `"live"`},
+		{"with the author", "/posts/" + live.Id.Hex() + "?expand=user", http.StatusOK, `"author@example.com"`},
+		{"unknown expansion", "/posts/" + live.Id.Hex() + "?expand=comments", http.StatusBadRequest, "comments"},
+		{"invalid id", "/posts/nope", http.StatusBadRequest, "postId"},
+		{"deleted", "/posts/" + deleted.Id.Hex(), http.StatusNotFound, "Post not found"},
+		{"missing", "/posts/" + primitive.NewObjectID().Hex(), http.StatusNotFound, "Post not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(http.MethodGet, "/posts/:postId", tt.target, "", primitive.NilObjectID,
+				middleware.SelectFields(query.PostWithAuthorFields), controllers.GetPost(repos.Posts))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("body %s does not contain %s", rec.Body, tt.contains)
+			}
+		})
+	}
+}
+
+func TestGetUsersPosts(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories()
+	post := &models.Post{Title: "title", Content: "content", User: primitive.NewObjectID()}
+	if err := repos.Posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Comments.Create(ctx, &models.Comment{Post: post.Id, User: post.User, Body: "comment"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		keys   []string
+		absent []string
+	}{
+		{"every field", "", []string{"id", "title", "content", "user", "createdAt", "commentCount"}, []string{"_id", "created_at"}},
+		{"selected fields", "?select=title", []string{"id", "title", "commentCount"}, []string{"content", "user"}},
+		{"cursor pages", "?after=", []string{"id", "title", "commentCount"}, []string{"_id"}},
+		{"id excluded", "?select=title,-id", []string{"title", "commentCount"}, []string{"id", "_id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(http.MethodGet, "/posts/user/:userId", "/posts/user/"+post.User.Hex()+tt.query, "", primitive.NilObjectID,
+				middleware.Paginate(configs.Defaults(configs.EnvTest).Pagination), middleware.SelectFields(query.PostFields),
+				controllers.GetUsersPosts(repos.Posts, repos.Comments))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+
+			var body struct {
+				Data struct {
+					Data []map[string]interface{} `json:"data"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || len(body.Data.Data) != 1 {
+				t.Fatalf("body %s does not hold one post: %v", rec.Body, err)
+			}
+			item := body.Data.Data[0]
+			for _, key := range tt.keys {
+				if _, ok := item[key]; !ok {
+					t.Errorf("item %v has no %s", item, key)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := item[key]; ok {
+					t.Errorf("item %v has %s", item, key)
+				}
+			}
+			if item["commentCount"] != 1.0 {
+				t.Errorf("commentCount = %v, want 1", item["commentCount"])
+			}
+		})
+	}
+}
+
+func TestDeletePost(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories()
+	post := &models.Post{Title: "title", Content: "content", User: primitive.NewObjectID()}
+	if err := repos.Posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := func(c *gin.Context) { c.Set("post", post) }
+	for i, status := range []int{http.StatusOK, http.StatusNotFound} {
+		rec := serve(http.MethodDelete, "/posts/:postId", "/posts/"+post.Id.Hex(), "", post.User, loaded, controllers.DeletePost(repos.Posts))
+		if rec.Code != status {
+			t.Errorf("delete %d: status = %d, want %d", i+1, rec.Code, status)
+		}
+	}
+
+	if _, err := repos.Posts.FindByID(ctx, post.Id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("the deleted post is still found: %v", err)
+	}
+	if _, err := repos.Posts.FindByID(repositories.WithDeleted(ctx), post.Id); err != nil {
+		t.Errorf("the post is gone instead of soft deleted: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// @descibe       Describe an error problem type
+// @route         GET /problems/:type
+// @access        Public
+func GetProblemType() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		problemType, ok := apperrors.LookupProblemType(c.Param("type"))
+		if !ok {
+			c.Error(apperrors.NotFound("Unknown problem type"))
+			return
+		}
+
+		c.JSON(http.StatusOK, problemType)
+	}
+}
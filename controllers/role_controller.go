@@ -1 +1,278 @@
 package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/requests"
+	"github.com/etg-dev/restApi/responses"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// @descibe       Create new role
+// @route         POST /roles
+// @access        Admin
+func CreateRole(roles repositories.RoleRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var input requests.RoleRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		role := models.Role{
+			Name:        input.Name,
+			Description: input.Description,
+			Permissions: uniqueStrings(input.Permissions),
+		}
+
+		err := roles.Create(ctx, &role)
+		if errors.Is(err, repositories.ErrDuplicate) {
+			c.Error(apperrors.Conflict("Role name already exists"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, responses.RoleResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": role}})
+	}
+}
+
+// @descibe       Get all roles
+// @route         GET /roles
+// @access        Authenticated
+func GetRoles(roles repositories.RoleRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		foundRoles, err := roles.FindAll(ctx)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.RoleResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": foundRoles}})
+	}
+}
+
+// @descibe       Get single role
+// @route         GET /roles/:id
+// @access        Authenticated
+func GetRole(roles repositories.RoleRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		id, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.Error(apperrors.InvalidID("id", err))
+			return
+		}
+
+		role, err := roles.FindByID(ctx, id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Role not found"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.RoleResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": role}})
+	}
+}
+
+// @descibe       Update single role
+// @route         PUT /roles/:id
+// @access        Admin
+func UpdateRole(roles repositories.RoleRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		id, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.Error(apperrors.InvalidID("id", err))
+			return
+		}
+
+		var input requests.RoleRequest
+		if err = requests.Bind(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+
+		role, err := roles.Update(ctx, &models.Role{
+			Id:          id,
+			Name:        input.Name,
+			Description: input.Description,
+			Permissions: uniqueStrings(input.Permissions),
+		})
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Role not found"))
+			return
+		}
+		if errors.Is(err, repositories.ErrDuplicate) {
+			c.Error(apperrors.Conflict("Role name already exists"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.RoleResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": role}})
+	}
+}
+
+// @descibe       Delete single role and revoke it from every user
+// @route         DELETE /roles/:id
+// @access        Admin
+func DeleteRole(roles repositories.RoleRepository, users repositories.UserRepository, tx repositories.Transactor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		id, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.Error(apperrors.InvalidID("id", err))
+			return
+		}
+
+		err = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := roles.Delete(ctx, id); err != nil {
+				return err
+			}
+			return users.RemoveRoleFromAll(ctx, id)
+		})
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Role not found"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.RoleResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": map[string]interface{}{"DeletedCount": 1}}})
+	}
+}
+
+// @descibe       Assign a role to a user
+// @route         POST /users/:id/roles/:roleId
+// @access        Admin
+func AssignRole(users repositories.UserRepository, roles repositories.RoleRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		userId, roleId, ok := userRoleParams(c)
+		if !ok {
+			return
+		}
+
+		if _, err := roles.FindByID(ctx, roleId); err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				c.Error(apperrors.NotFound("Role not found"))
+				return
+			}
+			c.Error(err)
+			return
+		}
+
+		err := users.AddRole(ctx, userId, roleId)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		user, err := users.FindByID(ctx, userId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.RoleResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": user}})
+	}
+}
+
+// @descibe       Revoke a role from a user
+// @route         DELETE /users/:id/roles/:roleId
+// @access        Admin
+func RevokeRole(users repositories.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		userId, roleId, ok := userRoleParams(c)
+		if !ok {
+			return
+		}
+
+		err := users.RemoveRole(ctx, userId, roleId)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		user, err := users.FindByID(ctx, userId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, responses.RoleResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": user}})
+	}
+}
+
+// userRoleParams parses :id and :roleId and writes the error response when either is malformed
+func userRoleParams(c *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	userId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.InvalidID("id", err))
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	roleId, err := primitive.ObjectIDFromHex(c.Param("roleId"))
+	if err != nil {
+		c.Error(apperrors.InvalidID("roleId", err))
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	return userId, roleId, true
+}
+
+// uniqueStrings drops empty and repeated values while keeping order
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
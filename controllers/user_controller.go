@@ -2,236 +2,348 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/auth"
 	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/logger"
 	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/query"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/etg-dev/restApi/requests"
 	"github.com/etg-dev/restApi/responses"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var userCollection *mongo.Collection = configs.GetCollection(configs.DB, "users")
-var actionCollection *mongo.Collection = configs.GetCollection(configs.DB, "actions")
-
-// @descibe       Create new user
+// @descibe       Create new user with any actions
 // @route         POST /users
-// @access        Public
-func CreateUser() gin.HandlerFunc {
+// @access        Admin
+func CreateUser(users repositories.UserRepository, actions repositories.ActionRepository, tx repositories.Transactor, policy configs.PasswordConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		var user models.User
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		if err := c.BindJSON(&user); err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+		var input requests.CreateUserRequest
+		if err := requests.Bind(c, &input); err != nil {
+			c.Error(err)
 			return
 		}
 
-		newAction := models.Action{
-			Actions: user.Action,
-		}
-
 		newUser := models.User{
-			Name:  user.Name,
-			Email: user.Email,
+			Name:  input.Name,
+			Email: input.Email,
 		}
 
-		resultInsertedUser, err := userCollection.InsertOne(ctx, newUser)
-		if err != nil {
-
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
+		// a password is optional, users without one can not log in until they reset it
+		if input.Password != "" {
+			if problems := auth.CheckPolicy(policy, input.Password); len(problems) > 0 {
+				c.Error(apperrors.Validation("Weak password", map[string]string{"password": auth.PolicyMessage(problems)}))
+				return
+			}
+			hash, err := auth.HashPassword(input.Password)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			newUser.PasswordHash = hash
 		}
 
-		userID, ok := resultInsertedUser.InsertedID.(primitive.ObjectID)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": "invalid ObjectID"}})
+		createdUser, err := createUser(ctx, users, actions, tx, &newUser, uniqueStrings(input.Action))
+		if errors.Is(err, repositories.ErrDuplicate) {
+			c.Error(apperrors.Conflict("Email already registered").Wrap(err))
 			return
 		}
-		newAction.User = userID
-
-		resultInsertedAction, err := actionCollection.InsertOne(ctx, newAction)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			return
 		}
 
-		actionID, ok := resultInsertedAction.InsertedID.(primitive.ObjectID)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": "invalid ObjectID"}})
-			return
+		responseUser := responses.UserResponse{
+			Status:  http.StatusCreated,
+			Message: "success",
+			Data: map[string]interface{}{
+				"id":       createdUser.Id,
+				"name":     createdUser.Name,
+				"email":    createdUser.Email,
+				"actionId": createdUser.ActionId,
+			},
 		}
 
-		newUser.ActionId = actionID
+		c.JSON(http.StatusCreated, responseUser)
+	}
+}
 
-		filter := bson.M{"_id": userID}
-		update := bson.M{"$set": bson.M{"actionId": actionID}}
-		_, err = userCollection.UpdateOne(ctx, filter, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
+// createUser inserts the user together with its actions document in one transaction and returns the stored user
+func createUser(ctx context.Context, users repositories.UserRepository, actions repositories.ActionRepository, tx repositories.Transactor, newUser *models.User, granted []string) (*models.User, error) {
+	err := tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := users.FindByEmail(ctx, newUser.Email); err == nil {
+			return repositories.ErrDuplicate
+		} else if !errors.Is(err, repositories.ErrNotFound) {
+			return err
 		}
 
-		var updatedUser models.User
-		err = userCollection.FindOne(ctx, filter).Decode(&updatedUser)
+		err := users.Create(ctx, newUser)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
+			return err
 		}
 
-		responseUser := responses.UserResponse{
-			Status:  http.StatusCreated,
-			Message: "success",
-			Data: map[string]interface{}{
-				"id":       updatedUser.Id,
-				"name":     updatedUser.Name,
-				"email":    updatedUser.Email,
-				"actionId": updatedUser.ActionId,
-			},
+		newAction := models.Action{
+			User:    newUser.Id,
+			Actions: granted,
 		}
 
-		c.JSON(http.StatusCreated, responseUser)
+		err = actions.Create(ctx, &newAction)
+		if err == nil {
+			err = users.SetActionID(ctx, newUser.Id, newAction.Id)
+		}
+		if err != nil {
+			// without transaction support nothing rolls the insert back, so undo it by hand
+			if undoErr := users.Purge(ctx, newUser.Id); undoErr != nil && !errors.Is(undoErr, repositories.ErrNotFound) {
+				logger.Warnf("could not remove half created user %s: %v", newUser.Id.Hex(), undoErr)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	return users.FindByID(ctx, newUser.Id)
 }
 
 // @descibe       Get all users
 // @route         GET /users
 // @access        Public
-func GetUsers() gin.HandlerFunc {
+func GetUsers(users repositories.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var users []models.User
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		filter := bson.M{}
-		cur, err := userCollection.Find(ctx, filter)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+		if _, cursorMode := c.Get("after"); cursorMode {
+			c.Error(apperrors.BadRequest("Users can only be paged with page"))
 			return
 		}
-
-		for cur.Next(ctx) {
-			var user models.User
-			err := cur.Decode(&user)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			users = append(users, user)
-		}
+		page := c.GetInt64("page")
+		pageSize := c.GetInt64("pageSize")
+
+		q := c.MustGet("query").(*query.Query)
+		selection := c.MustGet("selection").(*query.Selection)
+
+		foundUsers, total, err := users.List(ctx, repositories.ListOptions{
+			Skip:   (page - 1) * pageSize,
+			Limit:  pageSize,
+			Filter: q.Filter,
+			Sort:   q.Sort,
+			Count:  true,
+		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.UserResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": users}})
+		c.JSON(http.StatusOK, responses.UserResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]interface{}{"data": selection.Apply(foundUsers)},
+			Meta:    pageMeta(c, page, pageSize, total),
+		})
 	}
 }
 
 // @descibe       Get single user
 // @route         GET /user/:id
 // @access        Public
-func GetUser() gin.HandlerFunc {
+func GetUser(users repositories.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user models.User
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		user, ok := findUserParam(ctx, c, users)
+		if !ok {
+			return
+		}
+		selection := c.MustGet("selection").(*query.Selection)
+
+		c.JSON(http.StatusOK, responses.UserResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": selection.Apply(user)}})
+	}
+}
+
+// @descibe       Update single user
+// @route         PUT /user/:id
+// @access        Self or Admin
+func UpdateUser(users repositories.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
 		id, err := primitive.ObjectIDFromHex(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(apperrors.InvalidID("id", err))
+			return
+		}
+
+		var input requests.UpdateUserRequest
+		if err = requests.Bind(c, &input); err != nil {
+			c.Error(err)
 			return
 		}
 
-		err = userCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+		updatedUser, err := users.Update(ctx, &models.User{Id: id, Name: input.Name, Email: input.Email})
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("User not found with that id").Wrap(err))
+			return
+		}
+		if errors.Is(err, repositories.ErrDuplicate) {
+			c.Error(apperrors.Conflict("Email already registered").Wrap(err))
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.UserResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": user}})
+		c.JSON(http.StatusOK, responses.UserResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": updatedUser}})
 	}
 }
 
-// @descibe       Update single user
-// @route         PUT /user/:id
-// @access        Public
-func UpdateUser() gin.HandlerFunc {
+// @descibe       Delete single user and hide their comments, what happens to their posts depends on the cascade policy
+// @route         Delete /user/:id
+// @access        Self or Admin
+func DeleteUser(users repositories.UserRepository, posts repositories.PostRepository, comments repositories.CommentRepository, tx repositories.Transactor, cascade configs.CascadeConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user models.User
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
 		id, err := primitive.ObjectIDFromHex(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(apperrors.InvalidID("id", err))
 			return
 		}
 
-		err = c.BindJSON(&user)
+		err = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			return deleteUser(ctx, users, posts, comments, cascade, id, time.Now().UTC())
+		})
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("User not found with that id").Wrap(err))
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			return
 		}
 
-		filter := bson.M{"_id": id}
-		updateField := bson.M{"$set": bson.M{"name": user.Name, "email": user.Email}}
+		c.JSON(http.StatusOK, responses.UserResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": map[string]interface{}{"DeletedCount": 1}}})
+	}
+}
 
-		//! to return and update at the same time
-		options := options.FindOneAndUpdate().SetReturnDocument(options.After)
-		//
-		err = userCollection.FindOneAndUpdate(ctx, filter, updateField, options).Decode(&user)
+// deleteUser soft deletes the user and their comments and applies the cascade policy to their
+// posts. Every check runs before the first write so a refusal changes nothing even without a transaction.
+func deleteUser(ctx context.Context, users repositories.UserRepository, posts repositories.PostRepository, comments repositories.CommentRepository, cascade configs.CascadeConfig, id primitive.ObjectID, deletedAt time.Time) error {
+	var reassignTo primitive.ObjectID
+	switch cascade.Posts {
+	case configs.CascadeBlock:
+		count, err := posts.CountByUser(ctx, id)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
-			return
+			return err
+		}
+		if count > 0 {
+			return apperrors.Conflict(fmt.Sprintf("User still owns %d post(s), delete or move them first", count))
 		}
+	case configs.CascadeReassign:
+		// the config loader already checked that this is a valid id
+		reassignTo, _ = primitive.ObjectIDFromHex(cascade.ReassignTo)
+		if reassignTo == id {
+			return apperrors.Conflict("The user that receives the posts of deleted users can not be deleted")
+		}
+		if _, err := users.FindByID(ctx, reassignTo); errors.Is(err, repositories.ErrNotFound) {
+			return apperrors.Conflict("The user that receives the posts of deleted users does not exist")
+		} else if err != nil {
+			return err
+		}
+	}
 
-		c.JSON(http.StatusCreated, responses.UserResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": user}})
+	if err := users.Delete(ctx, id, deletedAt); err != nil {
+		return err
+	}
+
+	// comments are never reassigned, they go and come back with their author
+	err := comments.DeleteByUser(ctx, id, deletedAt)
+	if err == nil {
+		switch cascade.Posts {
+		case configs.CascadeDelete:
+			// the posts share the user's deletion time so a restore brings back exactly these
+			err = posts.DeleteByUser(ctx, id, deletedAt)
+		case configs.CascadeReassign:
+			err = posts.Reassign(ctx, id, reassignTo)
+		}
+	}
+	if err != nil {
+		// without transaction support nothing rolls the user back, so undo it by hand
+		if undoErr := comments.RestoreByUser(ctx, id, deletedAt); undoErr != nil {
+			logger.Warnf("could not restore the comments of user %s after a failed delete: %v", id.Hex(), undoErr)
+		}
+		if undoErr := users.Restore(ctx, id); undoErr != nil {
+			logger.Warnf("could not restore user %s after a failed delete: %v", id.Hex(), undoErr)
+		}
+		return err
 	}
+	return nil
 }
 
-// @descibe       Update single user
-// @route         Delete /user/:id
-// @access        Public
-func DeleteUser() gin.HandlerFunc {
+// @descibe       Restore a soft deleted user together with the posts and comments deleted with them
+// @route         POST /users/:id/restore
+// @access        Admin
+func RestoreUser(users repositories.UserRepository, posts repositories.PostRepository, comments repositories.CommentRepository, tx repositories.Transactor) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user models.User
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
 		id, err := primitive.ObjectIDFromHex(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "Id not found", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(apperrors.InvalidID("id", err))
 			return
 		}
 
-		err = c.BindJSON(&user)
+		user, err := users.FindByID(repositories.WithDeleted(ctx), id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("User not found with that id").Wrap(err))
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
+			return
+		}
+		if user.DeletedAt == nil {
+			c.Error(apperrors.Conflict("User is not deleted"))
 			return
 		}
 
-		filter := bson.M{"_id": id}
-
-		result, err := userCollection.DeleteOne(ctx, filter)
+		err = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := users.Restore(ctx, id); err != nil {
+				return err
+			}
+			if err := posts.RestoreByUser(ctx, id, *user.DeletedAt); err != nil {
+				return err
+			}
+			return comments.RestoreByUser(ctx, id, *user.DeletedAt)
+		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "error", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			return
 		}
 
-		if result.DeletedCount == 0 {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "User not found", Data: map[string]interface{}{"data": err.Error()}})
+		restored, err := users.FindByID(ctx, id)
+		if err != nil {
+			c.Error(err)
 			return
 		}
 
-		c.JSON(http.StatusCreated, responses.UserResponse{Status: http.StatusCreated, Message: "success", Data: map[string]interface{}{"data": result}})
+		c.JSON(http.StatusOK, responses.UserResponse{Status: http.StatusOK, Message: "success", Data: map[string]interface{}{"data": restored}})
 	}
 }
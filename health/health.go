@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc verifies a single dependency and returns an error when it is unusable
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the readiness of the service and each of its dependencies
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker holds the readiness flag and the dependency checks behind /readyz
+type Checker struct {
+	mu     sync.RWMutex
+	checks []namedCheck
+	ready  atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Register adds a dependency check, checks run in registration order
+func (h *Checker) Register(name string, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// SetReady flips the service in or out of rotation regardless of dependency state
+func (h *Checker) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func (h *Checker) Ready() bool {
+	return h.ready.Load()
+}
+
+// Check runs every dependency check and reports the service down if any fails or it is not ready
+func (h *Checker) Check(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := make([]namedCheck, len(h.checks))
+	copy(checks, h.checks)
+	h.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: map[string]CheckResult{}}
+	for _, c := range checks {
+		start := time.Now()
+		err := c.check(ctx)
+		result := CheckResult{
+			Status:    StatusUp,
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			result.Status = StatusDown
+			result.Error = err.Error()
+			report.Status = StatusDown
+		}
+		report.Checks[c.name] = result
+	}
+
+	if !h.Ready() {
+		report.Status = StatusDown
+	}
+	return report
+}
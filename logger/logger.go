@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+type Level int32
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = map[Level]string{
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// ParseLevel turns a level name such as "info" into a Level
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q", name)
+}
+
+var current = int32(InfoLevel)
+
+// SetLevel drops every message below the given level
+func SetLevel(level Level) {
+	atomic.StoreInt32(&current, int32(level))
+}
+
+// Enabled reports whether messages of the given level are written
+func Enabled(level Level) bool {
+	return int32(level) >= atomic.LoadInt32(&current)
+}
+
+func Debugf(format string, args ...interface{}) { logf(DebugLevel, format, args...) }
+func Infof(format string, args ...interface{})  { logf(InfoLevel, format, args...) }
+func Warnf(format string, args ...interface{})  { logf(WarnLevel, format, args...) }
+func Errorf(format string, args ...interface{}) { logf(ErrorLevel, format, args...) }
+
+func logf(level Level, format string, args ...interface{}) {
+	if !Enabled(level) {
+		return
+	}
+	log.Printf("["+strings.ToUpper(level.String())+"] "+format, args...)
+}
@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages, real providers plug in behind this interface
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer selected by the configuration
+func New(cfg configs.MailConfig) Mailer {
+	if cfg.Driver == "file" {
+		return &FileMailer{Dir: cfg.Dir}
+	}
+	return &LogMailer{}
+}
+
+// LogMailer writes every message to the log, for local development
+type LogMailer struct{}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	logger.Infof("mail to %s from %s: %s\n%s", msg.To, msg.From, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer drops every message as an .eml file into Dir
+type FileMailer struct {
+	Dir string
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), primitive.NewObjectID().Hex())
+	content := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		msg.From, msg.To, msg.Subject, time.Now().UTC().Format(time.RFC1123Z), msg.Body)
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(content), 0o600)
+}
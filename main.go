@@ -1,26 +1,40 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/etg-dev/restApi/app"
 	"github.com/etg-dev/restApi/configs"
-	"github.com/etg-dev/restApi/routes"
-	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	router := gin.Default()
+	cfg, err := configs.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("loading config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	application, err := app.Build(ctx, cfg)
+	if err != nil {
+		log.Fatalf("starting application: %v", err)
+	}
 
 	//! seed program
-	//err := seeders.DrainDB()
-	// err := seeders.InjectDB()
+	// db := configs.GetDatabase(application.Client, cfg.Mongo.Database)
+	//err := seeders.DrainDB(db)
+	// err := seeders.InjectDB(db)
 	// if err != nil {
 	// 	log.Fatal(err)
 	// }
 	//!
 
-	configs.ConnectDB()
-
-	routes.UserRoute(router)
-	routes.PostRoute(router)
-
-	router.Run("localhost:6000")
+	if err := application.Run(ctx); err != nil {
+		log.Fatalf("server stopped: %v", err)
+	}
 }
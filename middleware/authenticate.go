@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/logger"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// touchInterval limits how often a busy key writes its last used timestamp
+const touchInterval = time.Minute
+
+// Authenticate verifies the bearer access token or API key and puts the authenticated user id in the context
+func Authenticate(tokens *auth.TokenService, keys repositories.APIKeyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		token = strings.TrimSpace(token)
+		if token == "" || !(strings.EqualFold(scheme, "Bearer") || strings.EqualFold(scheme, "ApiKey")) {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.Error(apperrors.Unauthorized("Authorization bearer token or API key required"))
+			c.Abort()
+			return
+		}
+
+		if strings.EqualFold(scheme, "ApiKey") {
+			authenticateAPIKey(ctx, c, keys, token)
+			return
+		}
+
+		claims, err := tokens.Verify(ctx, token, auth.AccessToken)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			c.Error(apperrors.Unauthorized("Invalid or expired token"))
+			c.Abort()
+			return
+		}
+
+		userId, _ := claims.UserID()
+		c.Set("userId", userId) // set authenticated userId in context
+		c.Set("claims", claims)
+		// the repositories record the authenticated user on everything it writes
+		c.Request = c.Request.WithContext(repositories.WithActor(c.Request.Context(), userId))
+		c.Next()
+	}
+}
+
+// OptionalAuthenticate is Authenticate for public routes, requests without credentials pass through anonymously
+func OptionalAuthenticate(tokens *auth.TokenService, keys repositories.APIKeyRepository) gin.HandlerFunc {
+	authenticate := Authenticate(tokens, keys)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
+func authenticateAPIKey(ctx context.Context, c *gin.Context, keys repositories.APIKeyRepository, token string) {
+	key, err := keys.FindActive(ctx, auth.HashAPIKey(token))
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.Header("WWW-Authenticate", `ApiKey realm="api", error="invalid_key"`)
+		c.Error(apperrors.Unauthorized("Invalid or revoked API key"))
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval {
+		// a failed timestamp write must not fail the request
+		if err = keys.Touch(ctx, key.Id, now); err != nil {
+			logger.Warnf("could not record use of API key %s: %v", key.Id.Hex(), err)
+		}
+	}
+
+	c.Set("userId", key.User) // set authenticated userId in context
+	c.Set("apiKey", key)
+	c.Request = c.Request.WithContext(repositories.WithActor(c.Request.Context(), key.User))
+	c.Next()
+}
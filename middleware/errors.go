@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/logger"
+	"github.com/etg-dev/restApi/responses"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler renders the last error a handler or middleware attached with c.Error
+// as RFC 7807 problem details, or as the legacy envelope when the client asks for it
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		renderError(c, c.Errors.Last().Err)
+	}
+}
+
+// Recover turns a panic into an internal error response, it is meant for gin.CustomRecovery
+func Recover(c *gin.Context, recovered interface{}) {
+	renderError(c, fmt.Errorf("panic: %v", recovered))
+	c.Abort()
+}
+
+// LegacyErrorHeader lets older clients keep the status/message/data envelope for errors
+const LegacyErrorHeader = "X-Error-Format"
+
+func renderError(c *gin.Context, err error) {
+	appErr := apperrors.From(err)
+	if appErr.Status >= 500 {
+		logger.Errorf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	} else {
+		logger.Debugf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+
+	if strings.EqualFold(c.GetHeader(LegacyErrorHeader), "legacy") {
+		data := map[string]interface{}{"code": appErr.Code}
+		if len(appErr.Fields) > 0 {
+			data["errors"] = appErr.Fields
+		}
+		c.JSON(appErr.Status, responses.Response{Status: appErr.Status, Message: appErr.Message, Data: data})
+		return
+	}
+
+	// gin only sets its JSON content type when none is set yet
+	c.Header("Content-Type", apperrors.ProblemContentType)
+	c.JSON(appErr.Status, appErr.Problem(c.Request.URL.Path))
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IncludeDeleted lets admins see soft deleted users and posts with ?includeDeleted=true,
+// on public routes it must run after OptionalAuthenticate
+func IncludeDeleted(resolver *permissions.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		include, err := strconv.ParseBool(c.DefaultQuery("includeDeleted", "false"))
+		if err != nil {
+			c.Error(apperrors.BadRequest("includeDeleted must be true or false"))
+			c.Abort()
+			return
+		}
+		if !include {
+			c.Next()
+			return
+		}
+
+		userId, ok := c.Value("userId").(primitive.ObjectID)
+		if !ok {
+			c.Error(apperrors.Unauthorized("Authentication required to include deleted records"))
+			c.Abort()
+			return
+		}
+
+		// the resolver only finds users that are not deleted themselves
+		isAdmin, err := hasPermission(ctx, c, resolver, userId, permissions.Admin)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.Unauthorized("User not found with that id"))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !isAdmin {
+			c.Error(apperrors.Forbidden("Only admins can include deleted records"))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(repositories.WithDeleted(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/etg-dev/restApi/query"
+	"github.com/gin-gonic/gin"
+)
+
+// ListQuery parses the filter and sort parameters against the resource's whitelist and
+// puts the result in the context as "query"
+func ListQuery(schema query.Schema) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q, err := schema.Parse(c.Request.URL.Query())
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Set("query", q)
+		c.Next()
+	}
+}
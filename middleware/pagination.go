@@ -1,31 +1,58 @@
 package middleware
 
 import (
-	"net/http"
 	"strconv"
 
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/configs"
 	"github.com/gin-gonic/gin"
 )
 
-func Paginate() gin.HandlerFunc {
+// Paginate reads page and pageSize, clamping pageSize to the configured maximum. A request
+// with an after parameter, even an empty one for the first page, pages by cursor instead.
+func Paginate(cfg configs.PaginationConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		pageStr := c.DefaultQuery("page", "1")
-		pageSizeStr := c.DefaultQuery("pageSize", "10")
+		pageSizeStr := c.DefaultQuery("pageSize", strconv.FormatInt(cfg.DefaultPageSize, 10))
 
 		pageSize, err := strconv.ParseInt(pageSizeStr, 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pageSize"})
+			c.Error(apperrors.BadRequest("Invalid pageSize"))
+			c.Abort()
 			return
 		}
+		if pageSize < 1 {
+			c.Error(apperrors.BadRequest("pageSize must be at least 1"))
+			c.Abort()
+			return
+		}
+		if pageSize > cfg.MaxPageSize {
+			pageSize = cfg.MaxPageSize
+		}
 
 		page, err := strconv.ParseInt(pageStr, 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+			c.Error(apperrors.BadRequest("Invalid page"))
+			c.Abort()
 			return
 		}
+		if page < 1 {
+			c.Error(apperrors.BadRequest("page must be at least 1"))
+			c.Abort()
+			return
+		}
+
+		if after, ok := c.GetQuery("after"); ok {
+			if _, hasPage := c.GetQuery("page"); hasPage {
+				c.Error(apperrors.BadRequest("page and after can not be combined"))
+				c.Abort()
+				return
+			}
+			c.Set("after", after)
+		}
 
-		c.Set("page", page-1)
+		c.Set("page", page)
 		c.Set("pageSize", pageSize)
 
 		c.Next()
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// authenticatedKey returns the API key the request authenticated with, nil for bearer tokens
+func authenticatedKey(c *gin.Context) *models.APIKey {
+	key, _ := c.Value("apiKey").(*models.APIKey)
+	return key
+}
+
+// hasPermission reports whether the user holds the permission, requests made with an
+// API key additionally need the permission among the key's scopes
+func hasPermission(ctx context.Context, c *gin.Context, resolver *permissions.Resolver, userId primitive.ObjectID, permission string) (bool, error) {
+	if key := authenticatedKey(c); key != nil && !contains(key.Scopes, permission) {
+		return false, nil
+	}
+	return resolver.Has(ctx, userId, permission)
+}
@@ -1,26 +1,22 @@
 package middleware
 
 import (
-	"net/http"
-	"strings"
-
+	"github.com/etg-dev/restApi/query"
 	"github.com/gin-gonic/gin"
 )
 
-func SelectFields(fields []string) gin.HandlerFunc {
+// SelectFields parses the select parameter against the fields of the resource and puts the
+// result in the context as "selection", nil when every field is wanted
+func SelectFields(fields query.Fields) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var selectedFields []string
-		if selectStr, ok := c.GetQuery("select"); ok {
-			selectedFields = strings.Split(selectStr, ",")
-		}
-		for _, field := range selectedFields {
-			if !contains(fields, field) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid field selected"})
-				c.Abort()
-				return
-			}
+		selection, err := fields.Select(c.Query("select"))
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
 		}
-		c.Set("selectedFields", selectedFields)
+
+		c.Set("selection", selection)
 		c.Next()
 	}
 }
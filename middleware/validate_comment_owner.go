@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ValidateCommentOwner loads the :id comment and only lets its author or an admin through
+func ValidateCommentOwner(comments repositories.CommentRepository, resolver *permissions.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		userId, ok := c.MustGet("userId").(primitive.ObjectID)
+		if !ok {
+			c.Error(apperrors.Unauthorized("Authentication required"))
+			c.Abort()
+			return
+		}
+
+		commentId, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.Error(apperrors.InvalidID("id", err))
+			c.Abort()
+			return
+		}
+
+		comment, err := comments.FindByID(ctx, commentId)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Comment not found with that id").Wrap(err))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if comment.User != userId {
+			isAdmin, err := hasPermission(ctx, c, resolver, userId, permissions.Admin)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			if !isAdmin {
+				c.Error(apperrors.Forbidden("Only the author of this comment can modify it"))
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("comment", comment)
+		c.Next()
+	}
+}
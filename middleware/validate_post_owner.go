@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ValidatePostOwner loads the :postId post and only lets its owner or an admin through
+func ValidatePostOwner(posts repositories.PostRepository, resolver *permissions.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		// Get userId from context
+		userId, ok := c.MustGet("userId").(primitive.ObjectID)
+		if !ok {
+			c.Error(apperrors.Unauthorized("Authentication required"))
+			c.Abort()
+			return
+		}
+
+		postId, err := primitive.ObjectIDFromHex(c.Param("postId"))
+		if err != nil {
+			c.Error(apperrors.InvalidID("postId", err))
+			c.Abort()
+			return
+		}
+
+		post, err := posts.FindByID(ctx, postId)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.NotFound("Post not found with that id").Wrap(err))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		// Owners may always change their own posts, everybody else needs the admin action
+		if post.User != userId {
+			isAdmin, err := hasPermission(ctx, c, resolver, userId, permissions.Admin)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			if !isAdmin {
+				c.Error(apperrors.Forbidden("Only the owner of this post can modify it"))
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("post", post) // set post in context
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/etg-dev/restApi/middleware"
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func TestValidatePostOwner(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories()
+	resolver := permissions.NewResolver(repos)
+
+	users := map[string]*models.User{}
+	for name, actions := range map[string][]string{"owner": nil, "other": {permissions.Update}, "admin": {permissions.Admin}} {
+		user := &models.User{Name: name, Email: name + "@example.com"}
+		if err := repos.Users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repos.Actions.Replace(ctx, user.Id, actions); err != nil {
+			t.Fatal(err)
+		}
+		users[name] = user
+	}
+	post := &models.Post{Title: "title", Content: "content", User: users["owner"].Id}
+	if err := repos.Posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		as     string
+		postId string
+		status int
+	}{
+		{"owner", "owner", post.Id.Hex(), http.StatusOK},
+		{"other user", "other", post.Id.Hex(), http.StatusForbidden},
+		{"admin", "admin", post.Id.Hex(), http.StatusOK},
+		{"missing post", "owner", primitive.NewObjectID().Hex(), http.StatusNotFound},
+		{"invalid id", "owner", "nope", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.PUT("/posts/:postId",
+				func(c *gin.Context) { c.Set("userId", users[tt.as].Id) },
+				middleware.ValidatePostOwner(repos.Posts, resolver),
+				func(c *gin.Context) {
+					// the loaded post is handed on to the handler
+					if loaded, ok := c.Get("post"); !ok || loaded.(*models.Post).Id != post.Id {
+						t.Errorf("post in context = %v", loaded)
+					}
+					c.Status(http.StatusOK)
+				})
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/posts/"+tt.postId, nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
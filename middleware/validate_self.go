@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ValidateSelfOrAction lets the request through when :id is the authenticated user,
+// otherwise the user needs the given action. API keys are not trusted with their own
+// user's account and always need the action in their scopes.
+func ValidateSelfOrAction(resolver *permissions.Resolver, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		userId, ok := c.Value("userId").(primitive.ObjectID)
+		if !ok {
+			c.Error(apperrors.Unauthorized("Authentication required"))
+			c.Abort()
+			return
+		}
+
+		if c.Param("id") == userId.Hex() && authenticatedKey(c) == nil {
+			c.Next()
+			return
+		}
+
+		hasAction, err := hasPermission(ctx, c, resolver, userId, action)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !hasAction {
+			c.Error(apperrors.Forbidden(fmt.Sprintf("Only the user itself or a user with %s access can do this", action)))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
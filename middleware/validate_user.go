@@ -2,46 +2,45 @@ package middleware
 
 import (
 	"context"
-	"fmt"
-	"net/http"
+	"errors"
 	"time"
 
-	"github.com/etg-dev/restApi/configs"
-	"github.com/etg-dev/restApi/models"
-	"github.com/etg-dev/restApi/responses"
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/logger"
+	"github.com/etg-dev/restApi/repositories"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var userCollection *mongo.Collection = configs.GetCollection(configs.DB, "users")
-
-func ValidateUserID() gin.HandlerFunc {
+// ValidateUserID checks that the authenticated user still exists and puts it in the context, it must run after Authenticate
+func ValidateUserID(users repositories.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		// Get User ID from url
-		userId, err := primitive.ObjectIDFromHex(c.Param("userId"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, responses.UserResponse{Status: http.StatusInternalServerError, Message: "Id required", Data: map[string]interface{}{"data": err.Error()}})
+		// Get User ID from the authenticated identity
+		userId, ok := c.Value("userId").(primitive.ObjectID)
+		if !ok {
+			c.Error(apperrors.Unauthorized("Authentication required"))
 			c.Abort()
 			return
 		}
 
 		// Check user Id exist
-		filterUser := bson.M{"_id": userId}
-		var foundUser models.User
-		err = userCollection.FindOne(ctx, filterUser).Decode(&foundUser)
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.Error(apperrors.Unauthorized("User not found with that id"))
+			c.Abort()
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusBadRequest, responses.UserResponse{Status: http.StatusBadRequest, Message: "User not found with that id", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			c.Abort()
 			return
 		}
-		fmt.Println(filterUser)
+		logger.Debugf("validated user %s", userId.Hex())
+		c.Set("user", user) // set the authenticated user in context
 
-		c.Set("userId", userId) // set userId in context
 		c.Next()
 	}
 }
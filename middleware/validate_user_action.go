@@ -3,53 +3,38 @@ package middleware
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"time"
 
-	"github.com/etg-dev/restApi/configs"
-	"github.com/etg-dev/restApi/models"
-	"github.com/etg-dev/restApi/responses"
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/permissions"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var actionCollection *mongo.Collection = configs.GetCollection(configs.DB, "actions")
-
-func ValidateAction(action string) gin.HandlerFunc {
+func ValidateAction(resolver *permissions.Resolver, action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
 		// Get userId from context
-		userId, ok := c.Get("userId")
-		if !ok {
-			c.JSON(http.StatusBadRequest, responses.UserResponse{Status: http.StatusInternalServerError, Message: "Invalid userId"})
+		value, ok := c.Get("userId")
+		userId, isId := value.(primitive.ObjectID)
+		if !ok || !isId {
+			c.Error(apperrors.Unauthorized("Authentication required"))
 			c.Abort()
 			return
 		}
 
-		// Find user's actions
-		filterActions := bson.M{"user": userId}
-		var foundUserAction models.Action
-		err := actionCollection.FindOne(ctx, filterActions).Decode(&foundUserAction)
+		// Resolve the user's permissions from direct actions and roles, narrowed by the API key scopes
+		hasAction, err := hasPermission(ctx, c, resolver, userId, action)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: "There is no action for that user", Data: map[string]interface{}{"data": err.Error()}})
+			c.Error(err)
 			c.Abort()
 			return
 		}
 
-		// Check if user has the required action
-		hasAction := false
-		for _, a := range foundUserAction.Actions {
-			if a == action {
-				hasAction = true
-				break
-			}
-		}
-
 		if !hasAction {
-			c.JSON(http.StatusInternalServerError, responses.UserResponse{Status: http.StatusInternalServerError, Message: fmt.Sprintf("This user does not have access to %s", action)})
+			c.Error(apperrors.Forbidden(fmt.Sprintf("This user does not have access to %s", action)))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/etg-dev/restApi/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// posts written before created_at existed get the time encoded in their ObjectID
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "posts_created_at",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection(repositories.PostsCollection).UpdateMany(ctx,
+				bson.M{"created_at": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"created_at": bson.M{"$toDate": "$_id"}}}}},
+			)
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			// only the backfilled values match the id time exactly, newer posts keep theirs
+			_, err := db.Collection(repositories.PostsCollection).UpdateMany(ctx,
+				bson.M{"$expr": bson.M{"$eq": bson.A{"$created_at", bson.M{"$toDate": "$_id"}}}},
+				bson.M{"$unset": bson.M{"created_at": ""}},
+			)
+			return err
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"github.com/etg-dev/restApi/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// users created by the seeders or by hand have no actionId, they get their actions
+// document linked and an empty one created when there is none
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "users_action_id",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			users := db.Collection(repositories.UsersCollection)
+			actions := db.Collection(repositories.ActionsCollection)
+
+			cur, err := users.Find(ctx, bson.M{"actionId": bson.M{"$exists": false}})
+			if err != nil {
+				return err
+			}
+			defer cur.Close(ctx)
+
+			for cur.Next(ctx) {
+				var user models.User
+				if err = cur.Decode(&user); err != nil {
+					return err
+				}
+
+				actionId, err := actionIDForUser(ctx, actions, user.Id)
+				if err != nil {
+					return err
+				}
+				if _, err = users.UpdateOne(ctx, bson.M{"_id": user.Id}, bson.M{"$set": bson.M{"actionId": actionId}}); err != nil {
+					return err
+				}
+			}
+			return cur.Err()
+		},
+		// the linked ids are correct data, reverting leaves them in place
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return nil
+		},
+	})
+}
+
+func actionIDForUser(ctx context.Context, actions *mongo.Collection, userID primitive.ObjectID) (primitive.ObjectID, error) {
+	var action models.Action
+	err := actions.FindOne(ctx, bson.M{"user": userID}).Decode(&action)
+	if err == nil {
+		return action.Id, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, err
+	}
+
+	now := time.Now().UTC()
+	result, err := actions.InsertOne(ctx, models.Action{
+		User:        userID,
+		Actions:     []string{},
+		AuditFields: models.AuditFields{CreatedAt: now, UpdatedAt: now},
+	})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("invalid ObjectID")
+	}
+	return id, nil
+}
@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/etg-dev/restApi/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// auditedCollections hold documents whose timestamps the repositories maintain
+var auditedCollections = []string{repositories.UsersCollection, repositories.PostsCollection, repositories.ActionsCollection}
+
+// documents written before the repositories kept timestamps get the time encoded in their
+// ObjectID as created_at, and that as updated_at since no later change is known
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "audit_timestamps",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range auditedCollections {
+				collection := db.Collection(name)
+				_, err := collection.UpdateMany(ctx,
+					bson.M{"created_at": bson.M{"$exists": false}},
+					mongo.Pipeline{{{Key: "$set", Value: bson.M{"created_at": bson.M{"$toDate": "$_id"}}}}},
+				)
+				if err != nil {
+					return err
+				}
+				_, err = collection.UpdateMany(ctx,
+					bson.M{"updated_at": bson.M{"$exists": false}},
+					mongo.Pipeline{{{Key: "$set", Value: bson.M{"updated_at": "$created_at"}}}},
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			// as in posts_created_at only untouched backfilled values match the id time, posts
+			// keep created_at since that migration owns it
+			backfilled := bson.M{"$expr": bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$created_at", bson.M{"$toDate": "$_id"}}},
+				bson.M{"$eq": bson.A{"$updated_at", "$created_at"}},
+			}}}
+			for _, name := range auditedCollections {
+				unset := bson.M{"updated_at": ""}
+				if name != repositories.PostsCollection {
+					unset["created_at"] = ""
+				}
+				if _, err := db.Collection(name).UpdateMany(ctx, backfilled, bson.M{"$unset": unset}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrLocked is returned when another process is running migrations
+var ErrLocked = errors.New("migrations are locked by another process")
+
+const lockID = "migrations"
+
+// lockTTL is how long a lock survives a crashed owner, it is renewed before every migration
+const lockTTL = 10 * time.Minute
+
+type lock struct {
+	collection *mongo.Collection
+	owner      string
+}
+
+func newLock(collection *mongo.Collection) *lock {
+	host, _ := os.Hostname()
+	return &lock{collection: collection, owner: fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano())}
+}
+
+// acquire takes the lock when it is free, expired or already ours
+func (l *lock) acquire(ctx context.Context) error {
+	now := time.Now()
+	filter := bson.M{"_id": lockID, "$or": bson.A{
+		bson.M{"owner": l.owner},
+		bson.M{"expiresAt": bson.M{"$lt": now}},
+	}}
+	update := bson.M{"$set": bson.M{"owner": l.owner, "lockedAt": now, "expiresAt": now.Add(lockTTL)}}
+
+	// the upsert inserts the lock when there is none, a held lock makes it hit the unique _id
+	_, err := l.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		var held struct {
+			Owner     string    `bson:"owner"`
+			ExpiresAt time.Time `bson:"expiresAt"`
+		}
+		if findErr := l.collection.FindOne(ctx, bson.M{"_id": lockID}).Decode(&held); findErr == nil {
+			return fmt.Errorf("%w: held by %s until %s", ErrLocked, held.Owner, held.ExpiresAt.Format(time.RFC3339))
+		}
+		return ErrLocked
+	}
+	return err
+}
+
+func (l *lock) release(ctx context.Context) error {
+	_, err := l.collection.DeleteOne(ctx, bson.M{"_id": lockID, "owner": l.owner})
+	return err
+}
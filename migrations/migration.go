@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration is one versioned change to the data. Up and Down should be safe to run
+// again when they failed halfway, nothing is recorded until they return nil.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Down    func(ctx context.Context, db *mongo.Database) error
+}
+
+var registry []Migration
+
+// register adds a migration, every migration file calls it from init
+func register(m Migration) {
+	for _, existing := range registry {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migration version %d is used by %q and %q", m.Version, existing.Name, m.Name))
+		}
+	}
+	registry = append(registry, m)
+	sort.Slice(registry, func(i, j int) bool { return registry[i].Version < registry[j].Version })
+}
+
+// All returns the registered migrations ordered by version
+func All() []Migration {
+	all := make([]Migration, len(registry))
+	copy(all, registry)
+	return all
+}
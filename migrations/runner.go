@@ -0,0 +1,204 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/etg-dev/restApi/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MigrationsCollection records the applied versions
+	MigrationsCollection = "migrations"
+	// LocksCollection keeps the lock that serialises migration runs
+	LocksCollection = "migration_locks"
+)
+
+// Applied is the record of an applied migration
+type Applied struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"appliedAt"`
+}
+
+// State is a migration together with when it was applied, AppliedAt is nil while pending
+type State struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Runner struct {
+	db         *mongo.Database
+	applied    *mongo.Collection
+	lock       *lock
+	migrations []Migration
+}
+
+// NewRunner runs the registered migrations against db
+func NewRunner(db *mongo.Database) *Runner {
+	return &Runner{
+		db:         db,
+		applied:    db.Collection(MigrationsCollection),
+		lock:       newLock(db.Collection(LocksCollection)),
+		migrations: All(),
+	}
+}
+
+// Status lists every registered migration and when it was applied
+func (r *Runner) Status(ctx context.Context) ([]State, error) {
+	applied, err := r.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		state := State{Version: m.Version, Name: m.Name}
+		if record, ok := applied[m.Version]; ok {
+			appliedAt := record.AppliedAt
+			state.AppliedAt = &appliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Up applies every pending migration in version order
+func (r *Runner) Up(ctx context.Context) error {
+	return r.To(ctx, r.latest())
+}
+
+// Down reverts the most recently applied migration
+func (r *Runner) Down(ctx context.Context) error {
+	return r.locked(ctx, func(applied map[int]Applied) error {
+		for i := len(r.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[r.migrations[i].Version]; ok {
+				return r.revert(ctx, r.migrations[i])
+			}
+		}
+		logger.Infof("no migration to revert")
+		return nil
+	})
+}
+
+// To applies the pending migrations up to version and reverts the applied ones above it
+func (r *Runner) To(ctx context.Context, version int) error {
+	if version != 0 && !r.known(version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return r.locked(ctx, func(applied map[int]Applied) error {
+		for i := len(r.migrations) - 1; i >= 0; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; ok && m.Version > version {
+				if err := r.revert(ctx, m); err != nil {
+					return err
+				}
+			}
+		}
+		for _, m := range r.migrations {
+			if _, ok := applied[m.Version]; !ok && m.Version <= version {
+				if err := r.apply(ctx, m); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// locked runs fn while holding the migration lock
+func (r *Runner) locked(ctx context.Context, fn func(applied map[int]Applied) error) error {
+	if err := r.lock.acquire(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		// release even when ctx was cancelled so the next run does not wait for the TTL
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := r.lock.release(releaseCtx); err != nil {
+			logger.Warnf("releasing migration lock: %v", err)
+		}
+	}()
+
+	applied, err := r.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(applied)
+}
+
+func (r *Runner) apply(ctx context.Context, m Migration) error {
+	if err := r.lock.acquire(ctx); err != nil {
+		return err
+	}
+
+	logger.Infof("applying migration %d %s", m.Version, m.Name)
+	if err := m.Up(ctx, r.db); err != nil {
+		return fmt.Errorf("migration %d %s up: %w", m.Version, m.Name, err)
+	}
+
+	record := Applied{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}
+	_, err := r.applied.ReplaceOne(ctx, bson.M{"_id": m.Version}, record, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *Runner) revert(ctx context.Context, m Migration) error {
+	if err := r.lock.acquire(ctx); err != nil {
+		return err
+	}
+
+	logger.Infof("reverting migration %d %s", m.Version, m.Name)
+	if m.Down == nil {
+		return fmt.Errorf("migration %d %s can not be reverted", m.Version, m.Name)
+	}
+	if err := m.Down(ctx, r.db); err != nil {
+		return fmt.Errorf("migration %d %s down: %w", m.Version, m.Name, err)
+	}
+
+	_, err := r.applied.DeleteOne(ctx, bson.M{"_id": m.Version})
+	return err
+}
+
+func (r *Runner) appliedVersions(ctx context.Context) (map[int]Applied, error) {
+	cur, err := r.applied.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var records []Applied
+	if err = cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := map[int]Applied{}
+	for _, record := range records {
+		applied[record.Version] = record
+		if !r.known(record.Version) {
+			logger.Warnf("applied migration %d %s is not known to this build", record.Version, record.Name)
+		}
+	}
+	return applied, nil
+}
+
+func (r *Runner) latest() int {
+	if len(r.migrations) == 0 {
+		return 0
+	}
+	return r.migrations[len(r.migrations)-1].Version
+}
+
+func (r *Runner) known(version int) bool {
+	for _, m := range r.migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,9 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Action struct {
-	Id      primitive.ObjectID `bson:"_id,omitempty"`
-	User    primitive.ObjectID `bson:"user,omitempty"`
-	Actions []string           `bson:"actions,omitempty"`
+	Id      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User    primitive.ObjectID `bson:"user,omitempty" json:"user"`
+	Actions []string           `bson:"actions,omitempty" json:"actions"`
+
+	AuditFields `bson:",inline"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey lets a non interactive client act as its user within the key's scopes.
+// Only the hash of the key is stored, the prefix is kept so people can tell their keys apart.
+type APIKey struct {
+	Id         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User       primitive.ObjectID `bson:"user" json:"user"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"`
+	KeyHash    string             `bson:"keyHash" json:"-"`
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	LastUsedAt *time.Time         `bson:"lastUsedAt,omitempty" json:"lastUsedAt"`
+	RevokedAt  *time.Time         `bson:"revokedAt,omitempty" json:"revokedAt"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditFields records when a document was written and by whom, the repositories keep it current
+type AuditFields struct {
+	CreatedAt time.Time           `bson:"created_at,omitempty" json:"createdAt"`
+	UpdatedAt time.Time           `bson:"updated_at,omitempty" json:"updatedAt"`
+	CreatedBy *primitive.ObjectID `bson:"created_by,omitempty" json:"createdBy"`
+	UpdatedBy *primitive.ObjectID `bson:"updated_by,omitempty" json:"updatedBy"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry records who changed what on which resource
+type AuditEntry struct {
+	Id         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Actor      *primitive.ObjectID `bson:"actor,omitempty" json:"actor"`
+	Event      string              `bson:"event" json:"event"`
+	Resource   string              `bson:"resource" json:"resource"`
+	ResourceId primitive.ObjectID  `bson:"resourceId" json:"resourceId"`
+	Before     interface{}         `bson:"before,omitempty" json:"before"`
+	After      interface{}         `bson:"after,omitempty" json:"after"`
+	At         time.Time           `bson:"at" json:"at"`
+}
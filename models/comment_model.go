@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Comment struct {
+	Id   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Post primitive.ObjectID `bson:"post,omitempty" json:"post"`
+	User primitive.ObjectID `bson:"user,omitempty" json:"user"`
+	Body string             `bson:"body,omitempty" json:"body"`
+	// Parent is the comment this one replies to, nil for comments on the post itself
+	Parent *primitive.ObjectID `bson:"parent,omitempty" json:"parent"`
+	// Ancestors lists every comment above this one, the top level comment first, so a
+	// whole thread can be found or deleted with one query
+	Ancestors []primitive.ObjectID `bson:"ancestors,omitempty" json:"ancestors"`
+	// DeletedAt is set while the comment is soft deleted
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt"`
+
+	AuditFields `bson:",inline"`
+}
+
+// CommentThread is a comment with its replies nested below it. ReplyCount counts the
+// direct replies even when the depth limit left Replies empty.
+type CommentThread struct {
+	Comment    `bson:",inline"`
+	ReplyCount int             `bson:"replyCount" json:"replyCount"`
+	Replies    []CommentThread `bson:"replies,omitempty" json:"replies"`
+}
@@ -1,10 +1,24 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Post struct {
-	Id      primitive.ObjectID `bson:"_id,omitempty"`
-	Title   string             `bson:"title,omitempty"`
-	Content string             `bson:"content,omitempty"`
-	User    primitive.ObjectID `bson:"user,omitempty"`
+	Id      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title   string             `bson:"title,omitempty" json:"title"`
+	Content string             `bson:"content,omitempty" json:"content"`
+	User    primitive.ObjectID `bson:"user,omitempty" json:"user"`
+	// DeletedAt is set while the post is soft deleted
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt"`
+
+	AuditFields `bson:",inline"`
+}
+
+// PostWithAuthor is a post with its author document embedded
+type PostWithAuthor struct {
+	Post   `bson:",inline"`
+	Author *User `bson:"author,omitempty" json:"author"`
 }
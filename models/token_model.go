@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RevokedToken blocks a JWT by its id until the token would have expired anyway
+type RevokedToken struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Jti       string             `bson:"jti" json:"jti"`
+	User      primitive.ObjectID `bson:"user" json:"user"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+	// IssuedBefore is set on the entry that blocks every token of User issued before it
+	IssuedBefore time.Time `bson:"issuedBefore,omitempty" json:"issuedBefore"`
+}
@@ -1,12 +1,24 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type User struct {
-	Id       primitive.ObjectID `bson:"_id,omitempty"`
-	Name     string             `bson:"name,omitempty"`
-	Email    string             `bson:"email,omitempty"`
-	Posts    primitive.ObjectID `bson:"post,omitempty"`
-	Action   []string           `bson:"action,omitempty"`
-	ActionId primitive.ObjectID `bson:"actionId,omitempty"`
+	Id       primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name     string               `bson:"name,omitempty" json:"name"`
+	Email    string               `bson:"email,omitempty" json:"email"`
+	Posts    primitive.ObjectID   `bson:"post,omitempty" json:"posts"`
+	Action   []string             `bson:"action,omitempty" json:"action"`
+	ActionId primitive.ObjectID   `bson:"actionId,omitempty" json:"actionId"`
+	Roles    []primitive.ObjectID `bson:"roles,omitempty" json:"roles"`
+	// PasswordHash is the bcrypt hash of the user's password and never leaves the server
+	PasswordHash string `bson:"passwordHash,omitempty" json:"-"`
+
+	// DeletedAt is set while the user is soft deleted, queries skip such users unless asked not to
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt"`
+
+	AuditFields `bson:",inline"`
 }
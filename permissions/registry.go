@@ -0,0 +1,33 @@
+package permissions
+
+const (
+	Read   = "Read"
+	Create = "Create"
+	Update = "Update"
+	Delete = "Delete"
+	// Admin lets a user manage resources owned by other users
+	Admin = "Admin"
+)
+
+// Known lists every action a user, role or key can be granted
+var Known = []string{Read, Create, Update, Delete, Admin}
+
+func IsKnown(action string) bool {
+	for _, k := range Known {
+		if k == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Unknown returns the actions that are not in the registry
+func Unknown(actions []string) []string {
+	var unknown []string
+	for _, a := range actions {
+		if !IsKnown(a) {
+			unknown = append(unknown, a)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,71 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/etg-dev/restApi/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Resolver computes the permissions a user effectively holds
+type Resolver struct {
+	users   repositories.UserRepository
+	actions repositories.ActionRepository
+	roles   repositories.RoleRepository
+}
+
+func NewResolver(repos *repositories.Repositories) *Resolver {
+	return &Resolver{users: repos.Users, actions: repos.Actions, roles: repos.Roles}
+}
+
+// Effective returns the union of the user's direct actions and the permissions of every assigned role
+func (r *Resolver) Effective(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	user, err := r.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var effective []string
+	add := func(permissions []string) {
+		for _, p := range permissions {
+			if !seen[p] {
+				seen[p] = true
+				effective = append(effective, p)
+			}
+		}
+	}
+
+	action, err := r.actions.FindByUser(ctx, userID)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+	if action != nil {
+		add(action.Actions)
+	}
+
+	roles, err := r.roles.FindByIDs(ctx, user.Roles)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		add(role.Permissions)
+	}
+
+	return effective, nil
+}
+
+// Has reports whether the user effectively holds the permission
+func (r *Resolver) Has(ctx context.Context, userID primitive.ObjectID, permission string) (bool, error) {
+	effective, err := r.Effective(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range effective {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,210 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operators accepted as filter[field][operator], a filter without an operator means Eq
+const (
+	Eq       = "eq"
+	Ne       = "ne"
+	In       = "in"
+	Gt       = "gt"
+	Lt       = "lt"
+	Contains = "contains"
+	Exists   = "exists"
+)
+
+// maxInValues bounds the list an in filter may carry
+const maxInValues = 100
+
+// Kind tells how the query string values of a field are converted
+type Kind int
+
+const (
+	String Kind = iota
+	ObjectID
+	Time
+)
+
+// Field whitelists one field of a resource for filtering and sorting
+type Field struct {
+	// Name is the stored document field the query name stands for
+	Name      string
+	Kind      Kind
+	Operators []string
+	Sortable  bool
+}
+
+// Schema maps the field names a client may use to the fields they stand for
+type Schema map[string]Field
+
+// Query is a parsed filter and sort, ready to be used as mongo $match and $sort stages
+type Query struct {
+	Filter bson.M
+	Sort   bson.D
+}
+
+var filterKey = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// Parse reads filter[field][operator]=value and sort=-field,field from the query string.
+// Every problem is reported at once as a 400 with the offending parameters as fields.
+func (s Schema) Parse(values url.Values) (*Query, error) {
+	q := &Query{Filter: bson.M{}}
+	problems := map[string]string{}
+
+	for key, vals := range values {
+		if !strings.HasPrefix(key, "filter") {
+			continue
+		}
+		match := filterKey.FindStringSubmatch(key)
+		if match == nil {
+			problems[key] = "must look like filter[field] or filter[field][operator]"
+			continue
+		}
+		name, operator := match[1], match[2]
+		if operator == "" {
+			operator = Eq
+		}
+
+		field, ok := s[name]
+		if !ok {
+			problems[key] = fmt.Sprintf("%s can not be filtered, use one of %s", name, s.filterable())
+			continue
+		}
+		if !contains(field.Operators, operator) {
+			problems[key] = fmt.Sprintf("%s supports %s", name, strings.Join(field.Operators, ", "))
+			continue
+		}
+
+		condition, err := field.condition(operator, vals[len(vals)-1])
+		if err != nil {
+			problems[key] = err.Error()
+			continue
+		}
+		conditions, _ := q.Filter[field.Name].(bson.M)
+		if conditions == nil {
+			conditions = bson.M{}
+			q.Filter[field.Name] = conditions
+		}
+		for op, operand := range condition {
+			conditions[op] = operand
+		}
+	}
+
+	if param := values.Get("sort"); param != "" {
+		for _, name := range strings.Split(param, ",") {
+			direction := 1
+			if strings.HasPrefix(name, "-") {
+				direction, name = -1, name[1:]
+			}
+			field, ok := s[name]
+			if !ok || !field.Sortable {
+				problems["sort"] = fmt.Sprintf("can not sort by %q, use one of %s", name, s.sortable())
+				break
+			}
+			q.Sort = append(q.Sort, bson.E{Key: field.Name, Value: direction})
+		}
+	}
+
+	if len(problems) > 0 {
+		err := apperrors.BadRequest("Invalid filter or sort")
+		err.Fields = problems
+		return nil, err
+	}
+	return q, nil
+}
+
+// condition converts one filter into mongo operators on the field
+func (f Field) condition(operator, raw string) (bson.M, error) {
+	switch operator {
+	case Exists:
+		exists, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return bson.M{"$exists": exists}, nil
+	case Contains:
+		// the text is quoted so clients can not send expensive or broken patterns
+		return bson.M{"$regex": regexp.QuoteMeta(raw), "$options": "i"}, nil
+	case In:
+		parts := strings.Split(raw, ",")
+		if len(parts) > maxInValues {
+			return nil, fmt.Errorf("must list at most %d values", maxInValues)
+		}
+		list := bson.A{}
+		for _, part := range parts {
+			value, err := f.convert(part)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return bson.M{"$in": list}, nil
+	}
+
+	value, err := f.convert(raw)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"$" + operator: value}, nil
+}
+
+func (f Field) convert(raw string) (interface{}, error) {
+	switch f.Kind {
+	case ObjectID:
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a valid id")
+		}
+		return id, nil
+	case Time:
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be an RFC 3339 time")
+		}
+		return at.UTC(), nil
+	}
+	return raw, nil
+}
+
+func (s Schema) filterable() string {
+	var names []string
+	for name, field := range s {
+		if len(field.Operators) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (s Schema) sortable() string {
+	var names []string
+	for name, field := range s {
+		if field.Sortable {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParse(t *testing.T) {
+	id1, id2 := primitive.NewObjectID(), primitive.NewObjectID()
+	at := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		query  string
+		filter bson.M
+		sort   bson.D
+	}{
+		{
+			name:   "empty",
+			query:  "",
+			filter: bson.M{},
+		},
+		{
+			name:   "equals without an operator",
+			query:  "filter[title]=go",
+			filter: bson.M{"title": bson.M{"$eq": "go"}},
+		},
+		{
+			name:   "contains is quoted and case insensitive",
+			query:  "filter[title][contains]=a.b",
+			filter: bson.M{"title": bson.M{"$regex": `a\.b`, "$options": "i"}},
+		},
+		{
+			name:   "in converts every id",
+			query:  "filter[user][in]=" + id1.Hex() + "," + id2.Hex(),
+			filter: bson.M{"user": bson.M{"$in": bson.A{id1, id2}}},
+		},
+		{
+			name:   "exists",
+			query:  "filter[content][exists]=false",
+			filter: bson.M{"content": bson.M{"$exists": false}},
+		},
+		{
+			name:   "operators on one field are merged",
+			query:  "filter[createdAt][gt]=2023-05-01T14:00:00%2B02:00&filter[createdAt][lt]=2023-05-01T14:00:00Z",
+			filter: bson.M{"created_at": bson.M{"$gt": at, "$lt": at.Add(2 * time.Hour)}},
+		},
+		{
+			name:   "sort maps names and directions",
+			query:  "sort=-createdAt,title",
+			filter: bson.M{},
+			sort:   bson.D{{Key: "created_at", Value: -1}, {Key: "title", Value: 1}},
+		},
+		{
+			name:   "other parameters are ignored",
+			query:  "page=2&select=title",
+			filter: bson.M{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := Posts.Parse(values)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(q.Filter, tt.filter) {
+				t.Errorf("filter = %v, want %v", q.Filter, tt.filter)
+			}
+			if !reflect.DeepEqual(q.Sort, tt.sort) {
+				t.Errorf("sort = %v, want %v", q.Sort, tt.sort)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		fields []string
+	}{
+		{"unknown field", "filter[password]=x", []string{"filter[password]"}},
+		{"unsupported operator", "filter[content][eq]=x", []string{"filter[content][eq]"}},
+		{"malformed key", "filter[title=x", []string{"filter[title"}},
+		{"invalid id", "filter[user]=nope", []string{"filter[user]"}},
+		{"invalid time", "filter[createdAt][gt]=yesterday", []string{"filter[createdAt][gt]"}},
+		{"invalid bool", "filter[title][exists]=maybe", []string{"filter[title][exists]"}},
+		{"unsortable field", "sort=content", []string{"sort"}},
+		{"every problem at once", "filter[password]=x&filter[user]=nope&sort=-nope", []string{"filter[password]", "filter[user]", "sort"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Posts.Parse(values)
+
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) {
+				t.Fatalf("Parse(%q) error = %v, want an *apperrors.Error", tt.query, err)
+			}
+			var fields []string
+			for field := range appErr.Fields {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("problem fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestParseInLimit(t *testing.T) {
+	values := url.Values{}
+	list := "a"
+	for i := 0; i < maxInValues; i++ {
+		list += ",a"
+	}
+	values.Set("filter[title][in]", list)
+
+	if _, err := Posts.Parse(values); err == nil {
+		t.Errorf("Parse accepted an in filter with %d values", maxInValues+1)
+	}
+}
@@ -0,0 +1,40 @@
+package query
+
+import "github.com/etg-dev/restApi/models"
+
+var (
+	textOperators = []string{Eq, Ne, In, Contains, Exists}
+	idOperators   = []string{Eq, Ne, In, Exists}
+	timeOperators = []string{Gt, Lt, Exists}
+)
+
+// Posts lists what GET /posts may filter and sort by
+var Posts = withAuditFields(Schema{
+	"title":   {Name: "title", Kind: String, Operators: textOperators, Sortable: true},
+	"content": {Name: "content", Kind: String, Operators: []string{Contains, Exists}},
+	"user":    {Name: "user", Kind: ObjectID, Operators: idOperators},
+})
+
+// Users lists what GET /users may filter and sort by
+var Users = withAuditFields(Schema{
+	"name":  {Name: "name", Kind: String, Operators: textOperators, Sortable: true},
+	"email": {Name: "email", Kind: String, Operators: textOperators, Sortable: true},
+	"roles": {Name: "roles", Kind: ObjectID, Operators: idOperators},
+})
+
+// withAuditFields adds the timestamps and actors the repositories keep on every document
+func withAuditFields(s Schema) Schema {
+	s["createdAt"] = Field{Name: "created_at", Kind: Time, Operators: timeOperators, Sortable: true}
+	s["updatedAt"] = Field{Name: "updated_at", Kind: Time, Operators: timeOperators, Sortable: true}
+	s["createdBy"] = Field{Name: "created_by", Kind: ObjectID, Operators: idOperators}
+	s["updatedBy"] = Field{Name: "updated_by", Kind: ObjectID, Operators: idOperators}
+	return s
+}
+
+// PostFields lists what the post endpoints may select, PostWithAuthorFields adds the
+// author a single post can be expanded with
+var (
+	PostFields           = FieldsOf(models.Post{})
+	PostWithAuthorFields = FieldsOf(models.PostWithAuthor{})
+	UserFields           = FieldsOf(models.User{})
+)
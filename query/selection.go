@@ -0,0 +1,300 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Fields maps the field names a client may select to the stored document paths they stand
+// for. Nested structs are listed both as a whole (author) and field by field (author.name).
+type Fields map[string]string
+
+// FieldsOf derives the selectable fields from a model. Names are the go field names in
+// lower camel case, fields hidden from json such as password hashes are left out.
+func FieldsOf(model interface{}) Fields {
+	fields := Fields{}
+	fields.add(reflect.TypeOf(model), "", "")
+	return fields
+}
+
+func (f Fields) add(t reflect.Type, namePrefix, pathPrefix string) {
+	t = indirect(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		stored, inline, ok := bsonName(field)
+		if !ok || jsonName(field) == "-" {
+			continue
+		}
+		if inline {
+			f.add(field.Type, namePrefix, pathPrefix)
+			continue
+		}
+
+		name, path := namePrefix+lowerFirst(field.Name), pathPrefix+stored
+		f[name] = path
+		if isNested(field.Type) {
+			f.add(field.Type, name+".", path+".")
+		}
+	}
+}
+
+// Selection is the set of fields a client asked for, a nil selection keeps every field
+type Selection struct {
+	fields   Fields
+	selected map[string]bool
+}
+
+// All selects every field. Unlike a nil selection, its Apply still turns models into maps.
+func (f Fields) All() *Selection {
+	s := &Selection{fields: f, selected: map[string]bool{}}
+	for name := range f {
+		s.selected[name] = true
+	}
+	return s
+}
+
+// Select parses select=title,author.name or select=-content. Selected and excluded fields
+// can not be mixed, except that id may be excluded from a selection as mongo allows.
+func (f Fields) Select(param string) (*Selection, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	var included, excluded []string
+	var unknown []string
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		exclude := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if _, ok := f[name]; !ok {
+			unknown = append(unknown, fmt.Sprintf("%q", name))
+			continue
+		}
+		if exclude {
+			excluded = append(excluded, name)
+		} else {
+			included = append(included, name)
+		}
+	}
+
+	problem := ""
+	switch {
+	case len(unknown) > 0:
+		problem = fmt.Sprintf("%s can not be selected, use one of %s", strings.Join(unknown, ", "), f.names())
+	case len(included) > 0 && (len(excluded) > 1 || len(excluded) == 1 && excluded[0] != "id"):
+		problem = "can not mix selected and excluded fields, only id may be excluded from a selection"
+	}
+
+	s := &Selection{fields: f, selected: map[string]bool{}}
+	if problem == "" {
+		if len(included) > 0 {
+			s.selected["id"] = len(excluded) == 0
+			for _, name := range included {
+				s.mark(name, true)
+			}
+		} else {
+			for name := range f {
+				s.selected[name] = true
+			}
+			for _, name := range excluded {
+				s.mark(name, false)
+			}
+		}
+		if len(s.leaves()) == 0 {
+			problem = "excludes every field"
+		}
+	}
+
+	if problem != "" {
+		err := apperrors.BadRequest("Invalid field selection")
+		err.Fields = map[string]string{"select": problem}
+		return nil, err
+	}
+	return s, nil
+}
+
+// mark selects or drops a field together with everything nested in it. Dropping a nested
+// field also means the structs around it are no longer selected as a whole.
+func (s *Selection) mark(name string, selected bool) {
+	for other := range s.fields {
+		if other == name || strings.HasPrefix(other, name+".") {
+			s.selected[other] = selected
+		}
+	}
+	for parent := name; !selected && strings.Contains(parent, "."); {
+		parent = parent[:strings.LastIndex(parent, ".")]
+		s.selected[parent] = false
+	}
+}
+
+// leaves returns the selected fields that have no selectable fields nested in them
+func (s *Selection) leaves() []string {
+	var leaves []string
+	for name, selected := range s.selected {
+		if selected && !s.hasChildren(name) {
+			leaves = append(leaves, name)
+		}
+	}
+	sort.Strings(leaves)
+	return leaves
+}
+
+func (s *Selection) hasChildren(name string) bool {
+	for other := range s.fields {
+		if strings.HasPrefix(other, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// wants tells whether the field or anything nested in it is selected
+func (s *Selection) wants(name string) bool {
+	for other, selected := range s.selected {
+		if selected && (other == name || strings.HasPrefix(other, name+".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Projection converts the selection into a mongo inclusion projection, nil keeps every field
+func (s *Selection) Projection() bson.M {
+	if s == nil {
+		return nil
+	}
+
+	projection := bson.M{}
+	for _, name := range s.leaves() {
+		projection[s.fields[name]] = 1
+	}
+	if !s.selected["id"] {
+		projection["_id"] = 0
+	}
+	return projection
+}
+
+// Apply drops the unselected fields from a model or a slice of models. The result keeps
+// the json keys the model would have been rendered with.
+func (s *Selection) Apply(v interface{}) interface{} {
+	if s == nil {
+		return v
+	}
+	return s.apply(reflect.ValueOf(v), "")
+}
+
+func (s *Selection) apply(v reflect.Value, prefix string) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return s.apply(v.Elem(), prefix)
+	case reflect.Slice:
+		if !isNested(v.Type().Elem()) {
+			return v.Interface()
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = s.apply(v.Index(i), prefix)
+		}
+		return items
+	case reflect.Struct:
+		if !isNested(v.Type()) {
+			return v.Interface()
+		}
+		result := map[string]interface{}{}
+		s.applyStruct(v, prefix, result)
+		return result
+	}
+	return v.Interface()
+}
+
+func (s *Selection) applyStruct(v reflect.Value, prefix string, result map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		_, inline, ok := bsonName(field)
+		key := jsonName(field)
+		if !ok || key == "-" || !field.IsExported() {
+			continue
+		}
+		if inline {
+			s.applyStruct(reflect.Indirect(v.Field(i)), prefix, result)
+			continue
+		}
+
+		name := prefix + lowerFirst(field.Name)
+		if !s.wants(name) {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+		if isNested(field.Type) && !s.selected[name] {
+			result[key] = s.apply(v.Field(i), name+".")
+		} else {
+			result[key] = v.Field(i).Interface()
+		}
+	}
+}
+
+func (f Fields) names() string {
+	var names []string
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// bsonName returns the stored name of a struct field and whether it is inlined into its parent
+func bsonName(field reflect.StructField) (name string, inline bool, ok bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+	tag := field.Tag.Get("bson")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	for _, option := range parts[1:] {
+		if option == "inline" {
+			return "", true, true
+		}
+	}
+	if parts[0] != "" {
+		return parts[0], false, true
+	}
+	return strings.ToLower(field.Name), false, true
+}
+
+func jsonName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// isNested tells whether a field type is a struct whose fields can be selected one by one
+func isNested(t reflect.Type) bool {
+	t = indirect(t)
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
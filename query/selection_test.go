@@ -0,0 +1,133 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFieldsOf(t *testing.T) {
+	fields := FieldsOf(models.PostWithAuthor{})
+
+	tests := []struct {
+		name string
+		path string
+		ok   bool
+	}{
+		{"title", "title", true},
+		{"createdAt", "created_at", true},
+		{"author", "author", true},
+		{"author.name", "author.name", true},
+		{"author.createdAt", "author.created_at", true},
+		{"author.passwordHash", "", false},
+		{"post", "", false},
+	}
+
+	for _, tt := range tests {
+		path, ok := fields[tt.name]
+		if ok != tt.ok || path != tt.path {
+			t.Errorf("fields[%q] = %q, %v, want %q, %v", tt.name, path, ok, tt.path, tt.ok)
+		}
+	}
+}
+
+func TestSelectProjection(t *testing.T) {
+	tests := []struct {
+		name       string
+		param      string
+		projection bson.M
+	}{
+		{"nothing selected", "", nil},
+		{"included fields keep the id", "title,content", bson.M{"_id": 1, "title": 1, "content": 1}},
+		{"id can be excluded from a selection", "title,-id", bson.M{"title": 1, "_id": 0}},
+		{"nested fields are projected by path", "author.name", bson.M{"_id": 1, "author.name": 1}},
+		{"excluding a nested field keeps its siblings", "-author.roles", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := PostWithAuthorFields.Select(tt.param)
+			if err != nil {
+				t.Fatalf("Select(%q) failed: %v", tt.param, err)
+			}
+			projection := selection.Projection()
+			if tt.projection != nil || tt.param == "" {
+				if !reflect.DeepEqual(projection, tt.projection) {
+					t.Errorf("projection = %v, want %v", projection, tt.projection)
+				}
+				return
+			}
+			if projection["author.roles"] != nil || projection["author"] != nil {
+				t.Errorf("projection %v keeps the excluded field", projection)
+			}
+			if projection["author.name"] != 1 || projection["title"] != 1 {
+				t.Errorf("projection %v drops fields that were not excluded", projection)
+			}
+		})
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	tests := []string{
+		"nope",
+		"author.passwordHash",
+		"title,-content",
+		"-id,-title,-content,-user,-deletedAt,-createdAt,-updatedAt,-createdBy,-updatedBy",
+	}
+
+	for _, param := range tests {
+		if _, err := PostFields.Select(param); err == nil {
+			t.Errorf("Select(%q) succeeded, want an error", param)
+		}
+	}
+}
+
+func TestSelectApply(t *testing.T) {
+	post := models.PostWithAuthor{
+		Post:   models.Post{Id: primitive.NewObjectID(), Title: "title", Content: "content"},
+		Author: &models.User{Name: "name", Email: "mail@example.com", PasswordHash: "hash"},
+	}
+
+	tests := []struct {
+		name  string
+		param string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "selected fields and the id",
+			param: "title",
+			want:  map[string]interface{}{"id": post.Id, "title": "title"},
+		},
+		{
+			name:  "nested fields",
+			param: "author.name,-id",
+			want:  map[string]interface{}{"author": map[string]interface{}{"name": "name"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := PostWithAuthorFields.Select(tt.param)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := selection.Apply(&post)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var none *Selection
+	if got := none.Apply(post); !reflect.DeepEqual(got, post) {
+		t.Errorf("a nil selection changed the value to %v", got)
+	}
+
+	all, ok := PostWithAuthorFields.All().Apply(post).(map[string]interface{})
+	if !ok || all["content"] != "content" || all["author"] != post.Author {
+		t.Errorf("selecting all fields = %v, want every field as a map", all)
+	}
+}
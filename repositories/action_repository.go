@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ActionRepository interface {
+	// Create inserts the action document and sets its Id
+	Create(ctx context.Context, action *models.Action) error
+	FindByUser(ctx context.Context, userID primitive.ObjectID) (*models.Action, error)
+	// Replace sets the user's actions, creating the document when the user has none
+	Replace(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error)
+	// Add grants the actions the user does not hold yet, creating the document when needed
+	Add(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error)
+	Remove(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error)
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
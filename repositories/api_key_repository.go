@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error)
+	// FindActive returns the unrevoked key with the hash, anything else returns ErrNotFound
+	FindActive(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// Revoke marks the user's key as revoked, unknown and already revoked keys return ErrNotFound
+	Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, at time.Time) error
+	// RevokeByUser revokes every active key of the user
+	RevokeByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
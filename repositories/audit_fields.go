@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type actorKey struct{}
+
+// WithActor returns a context whose writes are recorded as made by the given user
+func WithActor(ctx context.Context, id primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, actorKey{}, id)
+}
+
+// ActorFrom returns the user writes made with ctx are recorded against, if any
+func ActorFrom(ctx context.Context) *primitive.ObjectID {
+	if id, ok := ctx.Value(actorKey{}).(primitive.ObjectID); ok {
+		return &id
+	}
+	return nil
+}
+
+// timestamp is the current time at the precision mongo stores, so memory and mongo agree
+func timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// stampCreated fills every audit field of a document about to be inserted
+func stampCreated(ctx context.Context, fields *models.AuditFields) {
+	now, actor := timestamp(), ActorFrom(ctx)
+	fields.CreatedAt, fields.UpdatedAt = now, now
+	fields.CreatedBy, fields.UpdatedBy = actor, actor
+}
+
+// stampUpdated records a change to a document kept in memory
+func stampUpdated(ctx context.Context, fields *models.AuditFields) {
+	fields.UpdatedAt = timestamp()
+	fields.UpdatedBy = ActorFrom(ctx)
+}
+
+// withUpdateStamp adds updated_at and updated_by to the $set of a mongo update
+func withUpdateStamp(ctx context.Context, update bson.M) bson.M {
+	set, ok := update["$set"].(bson.M)
+	if !ok {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updated_at"] = timestamp()
+	if actor := ActorFrom(ctx); actor != nil {
+		set["updated_by"] = *actor
+		return update
+	}
+
+	// an anonymous change must not stay attributed to the previous writer
+	unset, ok := update["$unset"].(bson.M)
+	if !ok {
+		unset = bson.M{}
+		update["$unset"] = unset
+	}
+	unset["updated_by"] = ""
+	return update
+}
+
+// withUpsertStamp is withUpdateStamp for upserts, which also need the created fields on insert
+func withUpsertStamp(ctx context.Context, update bson.M) bson.M {
+	update = withUpdateStamp(ctx, update)
+	insert := bson.M{"created_at": update["$set"].(bson.M)["updated_at"]}
+	if actor := ActorFrom(ctx); actor != nil {
+		insert["created_by"] = *actor
+	}
+	update["$setOnInsert"] = insert
+	return update
+}
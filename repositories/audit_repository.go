@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	// FindByResource returns the entries of one resource oldest first
+	FindByResource(ctx context.Context, resource string, id primitive.ObjectID) ([]models.AuditEntry, error)
+}
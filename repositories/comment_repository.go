@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CommentRepository interface {
+	// Create inserts the comment and sets its Id
+	Create(ctx context.Context, comment *models.Comment) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error)
+	// FindRoots returns a page of the live comments directly below parent, or on the post itself
+	// when parent is nil, oldest first, together with how many there are
+	FindRoots(ctx context.Context, postID primitive.ObjectID, parent *models.Comment, skip, limit int64) ([]models.Comment, int64, error)
+	// FindReplies returns the live replies below the roots down to depth levels, oldest first.
+	// The roots must be siblings, as FindRoots returns them.
+	FindReplies(ctx context.Context, roots []models.Comment, depth int) ([]models.Comment, error)
+	// Update replaces the body and returns the stored comment
+	Update(ctx context.Context, id primitive.ObjectID, body string) (*models.Comment, error)
+	// CountByPosts counts the live comments of each post, posts without comments are left out
+	CountByPosts(ctx context.Context, postIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
+	// Delete soft deletes the comment and every reply below it at the given time
+	Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// DeleteByUser soft deletes every live comment of the user at the given time, with the replies below them
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error
+	// RestoreByUser restores the comments DeleteByUser deleted at the given time
+	RestoreByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error
+	// Purge removes comments soft deleted before the given time and returns how many
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// PurgeByUser removes every comment of the user for good, with the replies below them
+	PurgeByUser(ctx context.Context, userID primitive.ObjectID) error
+	// PurgeOrphans removes the comments of posts that no longer exist and returns how many
+	PurgeOrphans(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed or was made for another sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position after the last document of a page: the sort it was made
+// for and that document's values of the sort keys
+type cursor struct {
+	Sort   bson.D `bson:"s"`
+	Values bson.A `bson:"v"`
+}
+
+// encodeCursor builds the opaque cursor that continues after doc
+func encodeCursor(sort bson.D, doc bson.M) (string, error) {
+	c := cursor{Sort: sort}
+	for _, key := range sort {
+		c.Values = append(c.Values, doc[key.Key])
+	}
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses a cursor and checks that it was made for sort
+func decodeCursor(value string, sort bson.D) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err = bson.Unmarshal(data, &c); err != nil || len(c.Values) != len(sort) || len(c.Sort) != len(sort) {
+		return nil, ErrInvalidCursor
+	}
+	for i, key := range sort {
+		if c.Sort[i].Key != key.Key || direction(c.Sort[i].Value) != direction(key.Value) {
+			return nil, ErrInvalidCursor
+		}
+		// the values end up in the filter, so a client must not be able to smuggle operators in
+		if !cursorValue(key.Key, c.Values[i]) {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return &c, nil
+}
+
+// cursorValue reports whether v can be the value of a sort key: a scalar, and an ObjectID for _id
+func cursorValue(key string, v interface{}) bool {
+	switch v.(type) {
+	case primitive.ObjectID:
+		return true
+	case nil, string, bool, int32, int64, float64, primitive.DateTime:
+		return key != "_id"
+	}
+	return false
+}
+
+// after is the keyset condition matching only documents that sort after the cursor.
+// Null and missing values sort before everything else, and $gt or $lt never match them,
+// so a null position and the null bucket of a descending key get their own conditions.
+func (c *cursor) after() bson.M {
+	var or bson.A
+	for i, key := range c.Sort {
+		condition := bson.M{}
+		for j := 0; j < i; j++ {
+			condition[c.Sort[j].Key] = c.Values[j]
+		}
+		value, descending := c.Values[i], direction(key.Value) < 0
+		switch {
+		case value == nil && descending:
+			// nothing sorts after null in descending order
+			continue
+		case value == nil:
+			condition[key.Key] = bson.M{"$ne": nil}
+		case descending:
+			condition["$or"] = bson.A{bson.M{key.Key: bson.M{"$lt": value}}, bson.M{key.Key: nil}}
+		default:
+			condition[key.Key] = bson.M{"$gt": value}
+		}
+		or = append(or, condition)
+	}
+	return bson.M{"$or": or}
+}
+
+// direction reads a sort direction whatever integer type it was decoded as
+func direction(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	}
+	return 1
+}
+
+// projectionWithSort makes sure the projection keeps the sort keys a cursor is built from
+// and returns the keys it had to add, so they can be dropped again afterwards
+func projectionWithSort(projection bson.M, sort bson.D) (bson.M, []string) {
+	if len(projection) == 0 {
+		return projection, nil
+	}
+
+	include := false
+	for field, v := range projection {
+		if field != "_id" && isTruthy(v) {
+			include = true
+			break
+		}
+	}
+
+	merged := bson.M{}
+	for field, v := range projection {
+		merged[field] = v
+	}
+	var added []string
+	for _, key := range sort {
+		v, listed := merged[key.Key]
+		switch {
+		case include && (!listed || !isTruthy(v)):
+			merged[key.Key] = 1
+			added = append(added, key.Key)
+		case !include && listed && !isTruthy(v):
+			delete(merged, key.Key)
+			added = append(added, key.Key)
+		}
+	}
+	return merged, added
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	doc := bson.M{"_id": id, "title": "title", "created_at": primitive.NewDateTimeFromTime(at)}
+
+	tests := []struct {
+		name   string
+		sort   bson.D
+		values bson.A
+	}{
+		{"default post order", sortOrDefault(nil, newestFirst), bson.A{primitive.NewDateTimeFromTime(at), id}},
+		{"several keys", bson.D{{Key: "title", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}, bson.A{"title", primitive.NewDateTimeFromTime(at), id}},
+		{"missing keys are kept as null", bson.D{{Key: "content", Value: 1}, {Key: "_id", Value: 1}}, bson.A{nil, id}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := encodeCursor(tt.sort, doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := decodeCursor(value, tt.sort)
+			if err != nil {
+				t.Fatalf("decodeCursor failed: %v", err)
+			}
+			if !reflect.DeepEqual(c.Values, tt.values) {
+				t.Errorf("values = %v, want %v", c.Values, tt.values)
+			}
+			if matches(doc, c.after()) {
+				t.Errorf("the document the cursor was made from sorts after it")
+			}
+		})
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	sort := bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}
+	valid, err := encodeCursor(sort, bson.M{"_id": primitive.NewObjectID(), "created_at": primitive.NewDateTimeFromTime(time.Now())})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		sort  bson.D
+	}{
+		{"not base64", "!!!", sort},
+		{"not bson", "bm90IGJzb24", sort},
+		{"another key", valid, bson.D{{Key: "title", Value: -1}, {Key: "_id", Value: 1}}},
+		{"another direction", valid, bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{"fewer keys", valid, bson.D{{Key: "_id", Value: 1}}},
+		{"operator value", forgeCursor(t, sort, bson.A{bson.M{"$gt": nil}, primitive.NewObjectID()}), sort},
+		{"array value", forgeCursor(t, sort, bson.A{bson.A{1, 2}, primitive.NewObjectID()}), sort},
+		{"id of another kind", forgeCursor(t, sort, bson.A{primitive.NewDateTimeFromTime(time.Now()), "id"}), sort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.value, tt.sort); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+// forgeCursor encodes values the way a client could, without going through encodeCursor
+func forgeCursor(t *testing.T, sort bson.D, values bson.A) string {
+	t.Helper()
+	data, err := bson.Marshal(cursor{Sort: sort, Values: values})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestListCursorPaging(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemoryRepositories()
+	user := primitive.NewObjectID()
+	// posts written without an actor have no created_by, which sorts like null
+	actors := []context.Context{ctx, WithActor(ctx, primitive.NewObjectID()), WithActor(ctx, primitive.NewObjectID())}
+
+	for i := 0; i < 7; i++ {
+		post := &models.Post{Title: fmt.Sprintf("post %d", i%3), Content: "content", User: user}
+		if err := repos.Posts.Create(actors[i%len(actors)], post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		sort bson.D
+	}{
+		{"default order", nil},
+		{"sort key with ties", bson.D{{Key: "title", Value: 1}}},
+		{"descending sort key with ties", bson.D{{Key: "title", Value: -1}}},
+		{"sort key with nulls", bson.D{{Key: "created_by", Value: 1}}},
+		{"descending sort key with nulls", bson.D{{Key: "created_by", Value: -1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all, err := repos.Posts.List(ctx, ListOptions{Sort: tt.sort})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var paged []interface{}
+			after := ""
+			for pages := 0; ; pages++ {
+				if pages > len(all.Items) {
+					t.Fatal("cursor paging does not end")
+				}
+				result, err := repos.Posts.List(ctx, ListOptions{Sort: tt.sort, Limit: 3, Cursor: true, After: after})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, item := range result.Items {
+					paged = append(paged, item["_id"])
+				}
+				if result.Next == "" {
+					break
+				}
+				after = result.Next
+			}
+
+			var want []interface{}
+			for _, item := range all.Items {
+				want = append(want, item["_id"])
+			}
+			if !reflect.DeepEqual(paged, want) {
+				t.Errorf("paged ids = %v, want %v", paged, want)
+			}
+		})
+	}
+}
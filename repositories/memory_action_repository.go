@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryActionRepository struct {
+	mu      sync.RWMutex
+	actions map[primitive.ObjectID]models.Action
+}
+
+func NewMemoryActionRepository() ActionRepository {
+	return &memoryActionRepository{actions: map[primitive.ObjectID]models.Action{}}
+}
+
+func (r *memoryActionRepository) Create(ctx context.Context, action *models.Action) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if action.Id.IsZero() {
+		action.Id = primitive.NewObjectID()
+	}
+	stampCreated(ctx, &action.AuditFields)
+	r.actions[action.Id] = *action
+	return nil
+}
+
+func (r *memoryActionRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) (*models.Action, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, action := range r.actions {
+		if action.User == userID {
+			return &action, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryActionRepository) Replace(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	action := r.findOrCreate(ctx, userID)
+	action.Actions = append([]string(nil), actions...)
+	stampUpdated(ctx, &action.AuditFields)
+	r.actions[action.Id] = action
+	return &action, nil
+}
+
+func (r *memoryActionRepository) Add(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	action := r.findOrCreate(ctx, userID)
+	merged := append([]string(nil), action.Actions...)
+	for _, a := range actions {
+		if !containsString(merged, a) {
+			merged = append(merged, a)
+		}
+	}
+	action.Actions = merged
+	stampUpdated(ctx, &action.AuditFields)
+	r.actions[action.Id] = action
+	return &action, nil
+}
+
+func (r *memoryActionRepository) Remove(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, action := range r.actions {
+		if action.User != userID {
+			continue
+		}
+		var kept []string
+		for _, a := range action.Actions {
+			if !containsString(actions, a) {
+				kept = append(kept, a)
+			}
+		}
+		action.Actions = kept
+		stampUpdated(ctx, &action.AuditFields)
+		r.actions[id] = action
+		return &action, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryActionRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, action := range r.actions {
+		if action.User == userID {
+			delete(r.actions, id)
+		}
+	}
+	return nil
+}
+
+// findOrCreate must be called with the write lock held
+func (r *memoryActionRepository) findOrCreate(ctx context.Context, userID primitive.ObjectID) models.Action {
+	for _, action := range r.actions {
+		if action.User == userID {
+			return action
+		}
+	}
+	action := models.Action{Id: primitive.NewObjectID(), User: userID}
+	stampCreated(ctx, &action.AuditFields)
+	return action
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryActionRepository(t *testing.T) {
+	ctx := context.Background()
+	actions := NewMemoryActionRepository()
+	user := primitive.NewObjectID()
+
+	steps := []struct {
+		name string
+		run  func() error
+		want []string
+	}{
+		{"add creates the document", func() error { _, err := actions.Add(ctx, user, []string{"Read", "Create"}); return err }, []string{"Read", "Create"}},
+		{"add skips held actions", func() error { _, err := actions.Add(ctx, user, []string{"Create", "Update"}); return err }, []string{"Read", "Create", "Update"}},
+		{"remove", func() error { _, err := actions.Remove(ctx, user, []string{"Read", "Admin"}); return err }, []string{"Create", "Update"}},
+		{"replace", func() error { _, err := actions.Replace(ctx, user, []string{"Admin"}); return err }, []string{"Admin"}},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		found, err := actions.FindByUser(ctx, user)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if !reflect.DeepEqual(found.Actions, step.want) {
+			t.Errorf("%s: actions = %v, want %v", step.name, found.Actions, step.want)
+		}
+	}
+
+	if _, err := actions.Remove(ctx, primitive.NewObjectID(), []string{"Read"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("remove from a user without actions: error = %v, want ErrNotFound", err)
+	}
+	if err := actions.DeleteByUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := actions.FindByUser(ctx, user); !errors.Is(err, ErrNotFound) {
+		t.Errorf("after DeleteByUser: error = %v, want ErrNotFound", err)
+	}
+}
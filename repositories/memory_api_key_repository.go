@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys []models.APIKey
+}
+
+func NewMemoryAPIKeyRepository() APIKeyRepository {
+	return &memoryAPIKeyRepository{}
+}
+
+func (r *memoryAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key.Id.IsZero() {
+		key.Id = primitive.NewObjectID()
+	}
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var keys []models.APIKey
+	for _, key := range r.keys {
+		if key.User == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepository) FindActive(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash && key.RevokedAt == nil {
+			found := key
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryAPIKeyRepository) Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, key := range r.keys {
+		if key.Id == id && key.User == userID && key.RevokedAt == nil {
+			revokedAt := at
+			r.keys[i].RevokedAt = &revokedAt
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryAPIKeyRepository) RevokeByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, key := range r.keys {
+		if key.User == userID && key.RevokedAt == nil {
+			revokedAt := at
+			r.keys[i].RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, key := range r.keys {
+		if key.Id == id {
+			usedAt := at
+			r.keys[i].LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.keys[:0]
+	for _, key := range r.keys {
+		if key.User != userID {
+			kept = append(kept, key)
+		}
+	}
+	r.keys = kept
+	return nil
+}
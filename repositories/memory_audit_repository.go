@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []models.AuditEntry
+}
+
+func NewMemoryAuditRepository() AuditRepository {
+	return &memoryAuditRepository{}
+}
+
+func (r *memoryAuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.Id.IsZero() {
+		entry.Id = primitive.NewObjectID()
+	}
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *memoryAuditRepository) FindByResource(ctx context.Context, resource string, id primitive.ObjectID) ([]models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []models.AuditEntry
+	for _, entry := range r.entries {
+		if entry.Resource == resource && entry.ResourceId == id {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,263 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryCommentRepository struct {
+	mu       sync.RWMutex
+	comments []models.Comment
+	posts    PostRepository
+}
+
+// NewMemoryCommentRepository keeps comments in memory and checks for orphans against posts
+func NewMemoryCommentRepository(posts PostRepository) CommentRepository {
+	return &memoryCommentRepository{posts: posts}
+}
+
+func (r *memoryCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if comment.Id.IsZero() {
+		comment.Id = primitive.NewObjectID()
+	}
+	stampCreated(ctx, &comment.AuditFields)
+	r.comments = append(r.comments, *comment)
+	return nil
+}
+
+func (r *memoryCommentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, comment := range r.comments {
+		if comment.Id == id && visible(ctx, comment.DeletedAt) {
+			return &comment, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// FindRoots relies on comments being appended in the order they were written
+func (r *memoryCommentRepository) FindRoots(ctx context.Context, postID primitive.ObjectID, parent *models.Comment, skip, limit int64) ([]models.Comment, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var parentId primitive.ObjectID
+	if parent != nil {
+		parentId = parent.Id
+	}
+	var comments []models.Comment
+	var total int64
+	for _, comment := range r.comments {
+		if comment.Post != postID || !visible(ctx, comment.DeletedAt) {
+			continue
+		}
+		if (comment.Parent == nil) != (parent == nil) || comment.Parent != nil && *comment.Parent != parentId {
+			continue
+		}
+		total++
+		if total > skip && (limit <= 0 || int64(len(comments)) < limit) {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, total, nil
+}
+
+func (r *memoryCommentRepository) FindReplies(ctx context.Context, roots []models.Comment, depth int) ([]models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	ids := map[primitive.ObjectID]bool{}
+	for _, root := range roots {
+		ids[root.Id] = true
+	}
+	maxAncestors := len(roots[0].Ancestors) + depth
+
+	var comments []models.Comment
+	for _, comment := range r.comments {
+		if !visible(ctx, comment.DeletedAt) || len(comment.Ancestors) > maxAncestors {
+			continue
+		}
+		if !ids[comment.Id] && inSubtree(comment, ids) {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (r *memoryCommentRepository) Update(ctx context.Context, id primitive.ObjectID, body string) (*models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.comments {
+		if r.comments[i].Id != id || !visible(ctx, r.comments[i].DeletedAt) {
+			continue
+		}
+		r.comments[i].Body = body
+		stampUpdated(ctx, &r.comments[i].AuditFields)
+		comment := r.comments[i]
+		return &comment, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryCommentRepository) CountByPosts(ctx context.Context, postIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := map[primitive.ObjectID]bool{}
+	for _, id := range postIDs {
+		wanted[id] = true
+	}
+	counts := map[primitive.ObjectID]int64{}
+	for _, comment := range r.comments {
+		if wanted[comment.Post] && visible(ctx, comment.DeletedAt) {
+			counts[comment.Post]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *memoryCommentRepository) Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := false
+	for i := range r.comments {
+		comment := &r.comments[i]
+		if comment.DeletedAt != nil || (comment.Id != id && !hasAncestor(*comment, id)) {
+			continue
+		}
+		comment.DeletedAt = &at
+		stampUpdated(ctx, &comment.AuditFields)
+		deleted = true
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *memoryCommentRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	written := r.writtenBy(func(comment models.Comment) bool { return comment.User == userID && comment.DeletedAt == nil })
+	for i := range r.comments {
+		comment := &r.comments[i]
+		if comment.DeletedAt == nil && inSubtree(*comment, written) {
+			comment.DeletedAt = &at
+			stampUpdated(ctx, &comment.AuditFields)
+		}
+	}
+	return nil
+}
+
+func (r *memoryCommentRepository) RestoreByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deletedAt := func(comment models.Comment) bool { return comment.DeletedAt != nil && comment.DeletedAt.Equal(at) }
+	written := r.writtenBy(func(comment models.Comment) bool { return comment.User == userID && deletedAt(comment) })
+	for i := range r.comments {
+		comment := &r.comments[i]
+		if deletedAt(*comment) && inSubtree(*comment, written) {
+			comment.DeletedAt = nil
+			stampUpdated(ctx, &comment.AuditFields)
+		}
+	}
+	return nil
+}
+
+func (r *memoryCommentRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.removeWhere(func(comment models.Comment) bool {
+		return comment.DeletedAt != nil && comment.DeletedAt.Before(before)
+	}), nil
+}
+
+func (r *memoryCommentRepository) PurgeByUser(ctx context.Context, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	written := r.writtenBy(func(comment models.Comment) bool { return comment.User == userID })
+	r.removeWhere(func(comment models.Comment) bool { return inSubtree(comment, written) })
+	return nil
+}
+
+func (r *memoryCommentRepository) PurgeOrphans(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orphaned := map[primitive.ObjectID]bool{}
+	for _, comment := range r.comments {
+		if _, checked := orphaned[comment.Post]; checked {
+			continue
+		}
+		_, err := r.posts.FindByID(WithDeleted(ctx), comment.Post)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return 0, err
+		}
+		orphaned[comment.Post] = err != nil
+	}
+	return r.removeWhere(func(comment models.Comment) bool { return orphaned[comment.Post] }), nil
+}
+
+// writtenBy collects the ids of the matching comments, it must be called with the lock held
+func (r *memoryCommentRepository) writtenBy(match func(models.Comment) bool) map[primitive.ObjectID]bool {
+	ids := map[primitive.ObjectID]bool{}
+	for _, comment := range r.comments {
+		if match(comment) {
+			ids[comment.Id] = true
+		}
+	}
+	return ids
+}
+
+// removeWhere must be called with the write lock held
+func (r *memoryCommentRepository) removeWhere(match func(models.Comment) bool) int64 {
+	kept := r.comments[:0]
+	for _, comment := range r.comments {
+		if !match(comment) {
+			kept = append(kept, comment)
+		}
+	}
+	removed := int64(len(r.comments) - len(kept))
+	r.comments = kept
+	return removed
+}
+
+// inSubtree reports whether the comment is one of the roots or a reply below one of them
+func inSubtree(comment models.Comment, roots map[primitive.ObjectID]bool) bool {
+	if roots[comment.Id] {
+		return true
+	}
+	for _, ancestor := range comment.Ancestors {
+		if roots[ancestor] {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAncestor(comment models.Comment, id primitive.ObjectID) bool {
+	for _, ancestor := range comment.Ancestors {
+		if ancestor == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// createThread writes the comments in order, each one replying to the comment at the index in
+// parents, -1 for comments on the post itself
+func createThread(t *testing.T, comments CommentRepository, post, user primitive.ObjectID, parents ...int) []models.Comment {
+	t.Helper()
+	var created []models.Comment
+	for _, parent := range parents {
+		comment := models.Comment{Post: post, User: user, Body: "body"}
+		if parent >= 0 {
+			comment.Parent = &created[parent].Id
+			comment.Ancestors = append(append([]primitive.ObjectID{}, created[parent].Ancestors...), created[parent].Id)
+		}
+		if err := comments.Create(context.Background(), &comment); err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, comment)
+	}
+	return created
+}
+
+func commentIds(comments []models.Comment) []primitive.ObjectID {
+	ids := []primitive.ObjectID{}
+	for _, comment := range comments {
+		ids = append(ids, comment.Id)
+	}
+	return ids
+}
+
+func TestMemoryCommentRepositoryThreads(t *testing.T) {
+	ctx := context.Background()
+	comments := NewMemoryCommentRepository(NewMemoryPostRepository(NewMemoryUserRepository()))
+	post, user := primitive.NewObjectID(), primitive.NewObjectID()
+	// 0 and 3 are on the post, 1 replies to 0, 2 to 1 and 4 to 0
+	c := createThread(t, comments, post, user, -1, 0, 1, -1, 0)
+	createThread(t, comments, primitive.NewObjectID(), user, -1)
+
+	tests := []struct {
+		name        string
+		parent      *models.Comment
+		skip, limit int64
+		depth       int
+		roots       []models.Comment
+		total       int64
+		replies     []models.Comment
+	}{
+		{"every root", nil, 0, 0, 1, []models.Comment{c[0], c[3]}, 2, []models.Comment{c[1], c[4]}},
+		{"a page of roots", nil, 1, 1, 1, []models.Comment{c[3]}, 2, []models.Comment{}},
+		{"two levels of replies", nil, 0, 1, 2, []models.Comment{c[0]}, 2, []models.Comment{c[1], c[2], c[4]}},
+		{"below a comment", &c[0], 0, 0, 1, []models.Comment{c[1], c[4]}, 2, []models.Comment{c[2]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots, total, err := comments.FindRoots(ctx, post, tt.parent, tt.skip, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != tt.total || !reflect.DeepEqual(commentIds(roots), commentIds(tt.roots)) {
+				t.Errorf("roots = %v of %d, want %v of %d", commentIds(roots), total, commentIds(tt.roots), tt.total)
+			}
+			replies, err := comments.FindReplies(ctx, roots, tt.depth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(commentIds(replies), commentIds(tt.replies)) {
+				t.Errorf("replies = %v, want %v", commentIds(replies), commentIds(tt.replies))
+			}
+		})
+	}
+}
+
+func TestMemoryCommentRepositoryByUser(t *testing.T) {
+	ctx := context.Background()
+	comments := NewMemoryCommentRepository(NewMemoryPostRepository(NewMemoryUserRepository()))
+	post, ann, bob := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	// bob replies to ann's comment and writes one of his own
+	anns := createThread(t, comments, post, ann, -1)
+	bobs := createThread(t, comments, post, bob, -1)
+	reply := models.Comment{Post: post, User: bob, Body: "reply", Parent: &anns[0].Id, Ancestors: []primitive.ObjectID{anns[0].Id}}
+	if err := comments.Create(ctx, &reply); err != nil {
+		t.Fatal(err)
+	}
+
+	live := func() []primitive.ObjectID {
+		t.Helper()
+		roots, _, err := comments.FindRoots(ctx, post, nil, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replies, err := comments.FindReplies(ctx, roots, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return commentIds(append(roots, replies...))
+	}
+
+	at := time.Now().UTC()
+	if err := comments.DeleteByUser(ctx, ann, at); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := live(), commentIds(bobs); !reflect.DeepEqual(got, want) {
+		t.Errorf("after DeleteByUser the live comments are %v, want %v", got, want)
+	}
+	if err := comments.RestoreByUser(ctx, ann, at.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := live(); len(got) != 1 {
+		t.Errorf("RestoreByUser with another time restored %v", got)
+	}
+	if err := comments.RestoreByUser(ctx, ann, at); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := live(), []primitive.ObjectID{anns[0].Id, bobs[0].Id, reply.Id}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after RestoreByUser the live comments are %v, want %v", got, want)
+	}
+}
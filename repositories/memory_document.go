@@ -0,0 +1,285 @@
+package repositories
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// toDocument converts a model into the document mongo would store for it
+func toDocument(v interface{}) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// project applies a flat mongo style projection to a document
+func project(doc bson.M, projection bson.M) bson.M {
+	if len(projection) == 0 {
+		return doc
+	}
+
+	include := false
+	for field, v := range projection {
+		if field != "_id" && isTruthy(v) {
+			include = true
+			break
+		}
+	}
+
+	result := bson.M{}
+	if include {
+		if v, ok := projection["_id"]; !ok || isTruthy(v) {
+			if id, ok := doc["_id"]; ok {
+				result["_id"] = id
+			}
+		}
+		for field, v := range projection {
+			if isTruthy(v) {
+				if value, ok := doc[field]; ok {
+					result[field] = value
+				}
+			}
+		}
+		return result
+	}
+
+	for field, value := range doc {
+		if v, ok := projection[field]; ok && !isTruthy(v) {
+			continue
+		}
+		result[field] = value
+	}
+	return result
+}
+
+func isTruthy(v interface{}) bool {
+	switch n := v.(type) {
+	case bool:
+		return n
+	case int:
+		return n != 0
+	case int32:
+		return n != 0
+	case int64:
+		return n != 0
+	case float64:
+		return n != 0
+	}
+	return true
+}
+
+// matches reports whether doc satisfies a flat mongo style filter
+func matches(doc bson.M, filter bson.M) bool {
+	for field, condition := range filter {
+		if field == "$or" || field == "$and" {
+			if !matchesAll(doc, field, condition) {
+				return false
+			}
+			continue
+		}
+		value, exists := doc[field]
+		operators, ok := condition.(bson.M)
+		if !ok {
+			operators = bson.M{"$eq": condition}
+		}
+		for operator, operand := range operators {
+			if !matchesOperator(value, exists, operator, operand, operators) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchesOperator evaluates one query operator, siblings holds the other operators on the field
+func matchesOperator(value interface{}, exists bool, operator string, operand interface{}, siblings bson.M) bool {
+	// like mongo, a null operand matches missing fields as well as null ones
+	if operand == nil && (operator == "$eq" || operator == "$ne") {
+		return (!exists || value == nil) == (operator == "$eq")
+	}
+
+	switch operator {
+	case "$exists":
+		want, _ := operand.(bool)
+		return exists == want
+	case "$ne":
+		return !exists || !anyElement(value, func(v interface{}) bool { return equalValues(v, operand) })
+	case "$options":
+		// read together with $regex
+		return true
+	}
+	if !exists {
+		return false
+	}
+
+	switch operator {
+	case "$eq":
+		return anyElement(value, func(v interface{}) bool { return equalValues(v, operand) })
+	case "$in":
+		list, _ := operand.(bson.A)
+		return anyElement(value, func(v interface{}) bool {
+			for _, candidate := range list {
+				if equalValues(v, candidate) {
+					return true
+				}
+			}
+			return false
+		})
+	case "$regex":
+		pattern, _ := operand.(string)
+		if options, _ := siblings["$options"].(string); strings.Contains(options, "i") {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false
+		}
+		return anyElement(value, func(v interface{}) bool {
+			text, ok := v.(string)
+			return ok && re.MatchString(text)
+		})
+	case "$gt", "$gte", "$lt", "$lte":
+		return anyElement(value, func(v interface{}) bool {
+			cmp, comparable := compareValues(v, operand)
+			if !comparable {
+				return false
+			}
+			switch operator {
+			case "$gt":
+				return cmp > 0
+			case "$gte":
+				return cmp >= 0
+			case "$lt":
+				return cmp < 0
+			}
+			return cmp <= 0
+		})
+	}
+	return false
+}
+
+// anyElement tests the value, or each element of an array value as mongo does
+func anyElement(value interface{}, test func(interface{}) bool) bool {
+	list, ok := value.(bson.A)
+	if !ok {
+		return test(value)
+	}
+	for _, v := range list {
+		if test(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAll evaluates the clauses of an $or or $and
+func matchesAll(doc bson.M, operator string, clauses interface{}) bool {
+	list, _ := clauses.(bson.A)
+	for _, clause := range list {
+		filter, _ := clause.(bson.M)
+		if matches(doc, filter) == (operator == "$or") {
+			return operator == "$or"
+		}
+	}
+	return operator == "$and"
+}
+
+// sortDocuments orders docs like a mongo $sort, missing and null fields sort before everything else
+func sortDocuments(docs []bson.M, order bson.D) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range order {
+			a, aok := docs[i][key.Key]
+			b, bok := docs[j][key.Key]
+			aok, bok = aok && a != nil, bok && b != nil
+			cmp := 0
+			switch {
+			case !aok && !bok:
+			case !aok:
+				cmp = -1
+			case !bok:
+				cmp = 1
+			default:
+				cmp, _ = compareValues(a, b)
+			}
+			if cmp == 0 {
+				continue
+			}
+			if direction(key.Value) < 0 {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
+
+func equalValues(a, b interface{}) bool {
+	cmp, ok := compareValues(a, b)
+	return ok && cmp == 0
+}
+
+// compareValues compares two document values of the same kind, ok is false when they can not be compared
+func compareValues(a, b interface{}) (cmp int, ok bool) {
+	a, b = normalizeValue(a), normalizeValue(b)
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			return compareOrdered(x < y, x > y), true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return compareOrdered(x.Before(y), x.After(y)), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			return compareOrdered(!x && y, x && !y), true
+		}
+	}
+	return 0, false
+}
+
+func compareOrdered(less, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+// normalizeValue maps the types a decoded document and a filter may hold onto one type per kind
+func normalizeValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case primitive.DateTime:
+		return n.Time()
+	case time.Time:
+		return n.Truncate(time.Millisecond)
+	case primitive.ObjectID:
+		// ObjectIDs are fixed length so their hex form sorts like the bytes
+		return n.Hex()
+	}
+	return v
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	resets []models.PasswordReset
+}
+
+func NewMemoryPasswordResetRepository() PasswordResetRepository {
+	return &memoryPasswordResetRepository{}
+}
+
+func (r *memoryPasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reset.Id.IsZero() {
+		reset.Id = primitive.NewObjectID()
+	}
+	r.resets = append(r.resets, *reset)
+	return nil
+}
+
+func (r *memoryPasswordResetRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, reset := range r.resets {
+		if reset.TokenHash != tokenHash || reset.UsedAt != nil || !reset.ExpiresAt.After(now) {
+			continue
+		}
+		usedAt := now
+		r.resets[i].UsedAt = &usedAt
+		consumed := r.resets[i]
+		return &consumed, nil
+	}
+	return nil, ErrNotFound
+}
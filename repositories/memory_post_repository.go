@@ -0,0 +1,314 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryPostRepository struct {
+	mu    sync.RWMutex
+	posts []models.Post
+	users UserRepository
+}
+
+// NewMemoryPostRepository keeps posts in memory and joins authors from users
+func NewMemoryPostRepository(users UserRepository) PostRepository {
+	return &memoryPostRepository{users: users}
+}
+
+func (r *memoryPostRepository) Create(ctx context.Context, post *models.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if post.Id.IsZero() {
+		post.Id = primitive.NewObjectID()
+	}
+	stampCreated(ctx, &post.AuditFields)
+	r.posts = append(r.posts, *post)
+	return nil
+}
+
+func (r *memoryPostRepository) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sort := sortOrDefault(opts.Sort, newestFirst)
+	var after bson.M
+	if opts.Cursor && opts.After != "" {
+		c, err := decodeCursor(opts.After, sort)
+		if err != nil {
+			return nil, err
+		}
+		after = c.after()
+	}
+
+	var matched []bson.M
+	for _, post := range r.posts {
+		if !visible(ctx, post.DeletedAt) {
+			continue
+		}
+		doc, err := toDocument(post)
+		if err != nil {
+			return nil, err
+		}
+		if matches(doc, opts.Filter) {
+			matched = append(matched, doc)
+		}
+	}
+	sortDocuments(matched, sort)
+
+	result := &ListResult{Total: int64(len(matched))}
+	if !opts.Count {
+		result.Total = 0
+	}
+
+	start := int(opts.Skip)
+	if opts.Cursor {
+		start = 0
+		for start < len(matched) && after != nil && !matches(matched[start], after) {
+			start++
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	var page []bson.M
+	for i := start; i < len(matched); i++ {
+		if opts.Limit > 0 && int64(len(page)) >= opts.Limit {
+			if opts.Cursor {
+				next, err := encodeCursor(sort, page[len(page)-1])
+				if err != nil {
+					return nil, err
+				}
+				result.Next = next
+			}
+			break
+		}
+		page = append(page, matched[i])
+	}
+
+	for _, doc := range page {
+		result.Items = append(result.Items, project(doc, opts.Projection))
+	}
+	return result, nil
+}
+
+// Search has no text index to use, so every word and phrase becomes a case insensitive
+// regex. There is no stemming and the language is only checked, not applied.
+func (r *memoryPostRepository) Search(ctx context.Context, opts SearchOptions) (*ListResult, error) {
+	if !supportedLanguage(opts.Language) {
+		return nil, ErrUnsupportedLanguage
+	}
+	terms := parseSearch(opts.Text)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []bson.M
+	for _, post := range r.posts {
+		if !visible(ctx, post.DeletedAt) {
+			continue
+		}
+		doc, err := toDocument(post)
+		if err != nil {
+			return nil, err
+		}
+		if score, ok := terms.score(doc); ok {
+			doc["score"] = score
+			matched = append(matched, doc)
+		}
+	}
+	sortDocuments(matched, bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}})
+
+	result := &ListResult{}
+	if opts.Count {
+		result.Total = int64(len(matched))
+	}
+
+	start := int(opts.Skip)
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(matched); i++ {
+		if opts.Limit > 0 && int64(len(result.Items)) >= opts.Limit {
+			break
+		}
+		score := matched[i]["score"].(float64)
+		delete(matched[i], "score")
+		result.Items = append(result.Items, searchHit(matched[i], terms, score, opts.Projection))
+	}
+	return result, nil
+}
+
+func (r *memoryPostRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var posts []models.Post
+	for _, post := range r.posts {
+		if post.User == userID && visible(ctx, post.DeletedAt) {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func (r *memoryPostRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, post := range r.posts {
+		if post.Id == id && visible(ctx, post.DeletedAt) {
+			return &post, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryPostRepository) FindByIDWithAuthor(ctx context.Context, id primitive.ObjectID) (*models.PostWithAuthor, error) {
+	post, err := r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.PostWithAuthor{Post: *post}
+	author, err := r.users.FindByID(ctx, post.User)
+	if err == nil {
+		result.Author = author
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *memoryPostRepository) Update(ctx context.Context, id primitive.ObjectID, update PostUpdate) (*models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.posts {
+		if r.posts[i].Id != id || !visible(ctx, r.posts[i].DeletedAt) {
+			continue
+		}
+		if update.Title != nil {
+			r.posts[i].Title = *update.Title
+		}
+		if update.Content != nil {
+			r.posts[i].Content = *update.Content
+		}
+		if update.Title != nil || update.Content != nil {
+			stampUpdated(ctx, &r.posts[i].AuditFields)
+		}
+		post := r.posts[i]
+		return &post, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryPostRepository) CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	posts, err := r.FindByUser(ctx, userID)
+	return int64(len(posts)), err
+}
+
+func (r *memoryPostRepository) Reassign(ctx context.Context, from, to primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.posts {
+		if r.posts[i].User == from && r.posts[i].DeletedAt == nil {
+			r.posts[i].User = to
+			stampUpdated(ctx, &r.posts[i].AuditFields)
+		}
+	}
+	return nil
+}
+
+func (r *memoryPostRepository) Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.posts {
+		if r.posts[i].Id == id && r.posts[i].DeletedAt == nil {
+			r.posts[i].DeletedAt = &at
+			stampUpdated(ctx, &r.posts[i].AuditFields)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryPostRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.posts {
+		if r.posts[i].User == userID && r.posts[i].DeletedAt == nil {
+			r.posts[i].DeletedAt = &at
+			stampUpdated(ctx, &r.posts[i].AuditFields)
+		}
+	}
+	return nil
+}
+
+func (r *memoryPostRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.posts {
+		if r.posts[i].Id == id && r.posts[i].DeletedAt != nil {
+			r.posts[i].DeletedAt = nil
+			stampUpdated(ctx, &r.posts[i].AuditFields)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryPostRepository) RestoreByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.posts {
+		if r.posts[i].User == userID && r.posts[i].DeletedAt != nil && r.posts[i].DeletedAt.Equal(at) {
+			r.posts[i].DeletedAt = nil
+			stampUpdated(ctx, &r.posts[i].AuditFields)
+		}
+	}
+	return nil
+}
+
+func (r *memoryPostRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.removeWhere(func(post models.Post) bool {
+		return post.DeletedAt != nil && post.DeletedAt.Before(before)
+	}), nil
+}
+
+func (r *memoryPostRepository) PurgeByUser(ctx context.Context, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeWhere(func(post models.Post) bool { return post.User == userID })
+	return nil
+}
+
+// removeWhere must be called with the write lock held
+func (r *memoryPostRepository) removeWhere(match func(models.Post) bool) int64 {
+	kept := r.posts[:0]
+	for _, post := range r.posts {
+		if !match(post) {
+			kept = append(kept, post)
+		}
+	}
+	removed := int64(len(r.posts) - len(kept))
+	r.posts = kept
+	return removed
+}
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryPostRepositoryUpdate(t *testing.T) {
+	ctx := context.Background()
+	posts := NewMemoryPostRepository(NewMemoryUserRepository())
+	post := &models.Post{Title: "title", Content: "content", User: primitive.NewObjectID()}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	title, content := "new title", "new content"
+	tests := []struct {
+		name    string
+		id      primitive.ObjectID
+		update  PostUpdate
+		title   string
+		content string
+		err     error
+	}{
+		{"title only", post.Id, PostUpdate{Title: &title}, "new title", "content", nil},
+		{"content only", post.Id, PostUpdate{Content: &content}, "new title", "new content", nil},
+		{"nothing", post.Id, PostUpdate{}, "new title", "new content", nil},
+		{"missing post", primitive.NewObjectID(), PostUpdate{Title: &title}, "", "", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := posts.Update(ctx, tt.id, tt.update)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if err == nil && (updated.Title != tt.title || updated.Content != tt.content) {
+				t.Errorf("post = %q, %q, want %q, %q", updated.Title, updated.Content, tt.title, tt.content)
+			}
+		})
+	}
+}
+
+func TestMemoryPostRepositoryByUser(t *testing.T) {
+	ctx := context.Background()
+	posts := NewMemoryPostRepository(NewMemoryUserRepository())
+	ann, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	for _, user := range []primitive.ObjectID{ann, ann, ann, bob} {
+		if err := posts.Create(ctx, &models.Post{Title: "title", Content: "content", User: user}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := func(user primitive.ObjectID) int64 {
+		t.Helper()
+		n, err := posts.CountByUser(ctx, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	at := time.Now().UTC()
+	if err := posts.DeleteByUser(ctx, ann, at); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(ann); n != 0 {
+		t.Errorf("after DeleteByUser ann has %d posts, want 0", n)
+	}
+	if err := posts.RestoreByUser(ctx, ann, at.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(ann); n != 0 {
+		t.Errorf("RestoreByUser with another time restored %d posts", n)
+	}
+	if err := posts.RestoreByUser(ctx, ann, at); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(ann); n != 3 {
+		t.Errorf("after RestoreByUser ann has %d posts, want 3", n)
+	}
+
+	if err := posts.Reassign(ctx, ann, bob); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := count(ann), count(bob); a != 0 || b != 4 {
+		t.Errorf("after Reassign ann has %d and bob %d posts, want 0 and 4", a, b)
+	}
+
+	result, err := posts.List(ctx, ListOptions{Filter: bson.M{"user": bob}, Projection: bson.M{"title": 1}, Count: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 4 || len(result.Items[0]) != 2 {
+		t.Errorf("List = %d items of %d, first %v", len(result.Items), result.Total, result.Items[0])
+	}
+}
+
+func TestMemoryPostRepositorySoftDelete(t *testing.T) {
+	ctx := context.Background()
+	posts := NewMemoryPostRepository(NewMemoryUserRepository())
+	post := &models.Post{Title: "title", Content: "content", User: primitive.NewObjectID()}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+		err  error
+	}{
+		{"restore a live post", func() error { return posts.Restore(ctx, post.Id) }, ErrNotFound},
+		{"delete", func() error { return posts.Delete(ctx, post.Id, time.Now().UTC()) }, nil},
+		{"find the deleted post", func() error { _, err := posts.FindByID(ctx, post.Id); return err }, ErrNotFound},
+		{"find it with deleted", func() error { _, err := posts.FindByID(WithDeleted(ctx), post.Id); return err }, nil},
+		{"delete twice", func() error { return posts.Delete(ctx, post.Id, time.Now().UTC()) }, ErrNotFound},
+		{"restore", func() error { return posts.Restore(ctx, post.Id) }, nil},
+		{"find the restored post", func() error { _, err := posts.FindByID(ctx, post.Id); return err }, nil},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); !errors.Is(err, step.err) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryRoleRepository struct {
+	mu    sync.RWMutex
+	order []primitive.ObjectID
+	roles map[primitive.ObjectID]models.Role
+}
+
+func NewMemoryRoleRepository() RoleRepository {
+	return &memoryRoleRepository{roles: map[primitive.ObjectID]models.Role{}}
+}
+
+func (r *memoryRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(role.Name, primitive.NilObjectID) {
+		return ErrDuplicate
+	}
+	if role.Id.IsZero() {
+		role.Id = primitive.NewObjectID()
+	}
+	r.roles[role.Id] = *role
+	r.order = append(r.order, role.Id)
+	return nil
+}
+
+func (r *memoryRoleRepository) FindAll(ctx context.Context) ([]models.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var roles []models.Role
+	for _, id := range r.order {
+		roles = append(roles, r.roles[id])
+	}
+	return roles, nil
+}
+
+func (r *memoryRoleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	role, ok := r.roles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &role, nil
+}
+
+func (r *memoryRoleRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var roles []models.Role
+	for _, id := range ids {
+		if role, ok := r.roles[id]; ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+func (r *memoryRoleRepository) Update(ctx context.Context, role *models.Role) (*models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[role.Id]; !ok {
+		return nil, ErrNotFound
+	}
+	if r.nameTaken(role.Name, role.Id) {
+		return nil, ErrDuplicate
+	}
+	r.roles[role.Id] = *role
+	updated := *role
+	return &updated, nil
+}
+
+func (r *memoryRoleRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.roles, id)
+	r.order = removeID(r.order, id)
+	return nil
+}
+
+func (r *memoryRoleRepository) nameTaken(name string, except primitive.ObjectID) bool {
+	for id, role := range r.roles {
+		if id != except && role.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryTokenRepository struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+	users   map[primitive.ObjectID]time.Time
+}
+
+func NewMemoryTokenRepository() TokenRepository {
+	return &memoryTokenRepository{revoked: map[string]time.Time{}, users: map[primitive.ObjectID]time.Time{}}
+}
+
+func (r *memoryTokenRepository) Revoke(ctx context.Context, token *models.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// drop entries whose tokens expired on their own, like the mongo TTL index would
+	now := time.Now()
+	for jti, expiresAt := range r.revoked {
+		if expiresAt.Before(now) {
+			delete(r.revoked, jti)
+		}
+	}
+	r.revoked[token.Jti] = token.ExpiresAt
+	return nil
+}
+
+func (r *memoryTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.revoked[jti]
+	return ok, nil
+}
+
+func (r *memoryTokenRepository) RevokeUser(ctx context.Context, userID primitive.ObjectID, before, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if before.After(r.users[userID]) {
+		r.users[userID] = before
+	}
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokedBefore(ctx context.Context, userID primitive.ObjectID) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.users[userID], nil
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import "context"
+
+type memoryTransactor struct{}
+
+// NewMemoryTransactor runs units of work directly, the memory repositories apply every
+// write immediately and have nothing to roll back
+func NewMemoryTransactor() Transactor {
+	return memoryTransactor{}
+}
+
+func (memoryTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUserRepository struct {
+	mu    sync.RWMutex
+	order []primitive.ObjectID
+	users map[primitive.ObjectID]models.User
+}
+
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{users: map[primitive.ObjectID]models.User{}}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTaken(user.Email, primitive.NilObjectID) {
+		return ErrDuplicate
+	}
+	if user.Id.IsZero() {
+		user.Id = primitive.NewObjectID()
+	}
+	stampCreated(ctx, &user.AuditFields)
+	r.users[user.Id] = *user
+	r.order = append(r.order, user.Id)
+	return nil
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, opts ListOptions) ([]models.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var docs []bson.M
+	for _, id := range r.order {
+		user, ok := r.users[id]
+		if !ok || !visible(ctx, user.DeletedAt) {
+			continue
+		}
+		doc, err := toDocument(user)
+		if err != nil {
+			return nil, 0, err
+		}
+		if matches(doc, opts.Filter) {
+			docs = append(docs, doc)
+		}
+	}
+	sortDocuments(docs, sortOrDefault(opts.Sort, oldestFirst))
+
+	var total int64
+	if opts.Count {
+		total = int64(len(docs))
+	}
+
+	start := int(opts.Skip)
+	if start < 0 {
+		start = 0
+	}
+	var users []models.User
+	for i := start; i < len(docs); i++ {
+		if opts.Limit > 0 && int64(len(users)) >= opts.Limit {
+			break
+		}
+		users = append(users, r.users[docs[i]["_id"].(primitive.ObjectID)])
+	}
+	return users, total, nil
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || !visible(ctx, user.DeletedAt) {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, id := range r.order {
+		if user := r.users[id]; user.Email == email && visible(ctx, user.DeletedAt) {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.Id]
+	if !ok || !visible(ctx, stored.DeletedAt) {
+		return nil, ErrNotFound
+	}
+	if r.emailTaken(user.Email, user.Id) {
+		return nil, ErrDuplicate
+	}
+	stored.Name = user.Name
+	stored.Email = user.Email
+	stampUpdated(ctx, &stored.AuditFields)
+	r.users[user.Id] = stored
+	return &stored, nil
+}
+
+func (r *memoryUserRepository) SetActionID(ctx context.Context, id, actionID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || !visible(ctx, stored.DeletedAt) {
+		return ErrNotFound
+	}
+	stored.ActionId = actionID
+	stampUpdated(ctx, &stored.AuditFields)
+	r.users[id] = stored
+	return nil
+}
+
+func (r *memoryUserRepository) SetPasswordHash(ctx context.Context, id primitive.ObjectID, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || !visible(ctx, stored.DeletedAt) {
+		return ErrNotFound
+	}
+	stored.PasswordHash = hash
+	stampUpdated(ctx, &stored.AuditFields)
+	r.users[id] = stored
+	return nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	stored.DeletedAt = &at
+	stampUpdated(ctx, &stored.AuditFields)
+	r.users[id] = stored
+	return nil
+}
+
+func (r *memoryUserRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || stored.DeletedAt == nil {
+		return ErrNotFound
+	}
+	stored.DeletedAt = nil
+	stampUpdated(ctx, &stored.AuditFields)
+	r.users[id] = stored
+	return nil
+}
+
+func (r *memoryUserRepository) DeletedBefore(ctx context.Context, before time.Time) ([]primitive.ObjectID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []primitive.ObjectID
+	for _, id := range r.order {
+		if deletedAt := r.users[id].DeletedAt; deletedAt != nil && deletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryUserRepository) Purge(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	r.order = removeID(r.order, id)
+	return nil
+}
+
+func (r *memoryUserRepository) AddRole(ctx context.Context, id, roleID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || !visible(ctx, stored.DeletedAt) {
+		return ErrNotFound
+	}
+	for _, existing := range stored.Roles {
+		if existing == roleID {
+			return nil
+		}
+	}
+	stored.Roles = append(stored.Roles, roleID)
+	stampUpdated(ctx, &stored.AuditFields)
+	r.users[id] = stored
+	return nil
+}
+
+func (r *memoryUserRepository) RemoveRole(ctx context.Context, id, roleID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || !visible(ctx, stored.DeletedAt) {
+		return ErrNotFound
+	}
+	stored.Roles = removeID(append([]primitive.ObjectID(nil), stored.Roles...), roleID)
+	stampUpdated(ctx, &stored.AuditFields)
+	r.users[id] = stored
+	return nil
+}
+
+func (r *memoryUserRepository) RemoveRoleFromAll(ctx context.Context, roleID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, stored := range r.users {
+		roles := removeID(append([]primitive.ObjectID(nil), stored.Roles...), roleID)
+		if len(roles) == len(stored.Roles) {
+			continue
+		}
+		stored.Roles = roles
+		stampUpdated(ctx, &stored.AuditFields)
+		r.users[id] = stored
+	}
+	return nil
+}
+
+func removeID(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	for i, v := range ids {
+		if v == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
+
+// emailTaken mirrors the unique email index of the mongo repository, soft deleted users
+// keep their email until they are purged
+func (r *memoryUserRepository) emailTaken(email string, except primitive.ObjectID) bool {
+	for id, user := range r.users {
+		if id != except && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func createUsers(t *testing.T, users UserRepository, names ...string) []*models.User {
+	t.Helper()
+	var created []*models.User
+	for _, name := range names {
+		user := &models.User{Name: name, Email: name + "@example.com"}
+		if err := users.Create(context.Background(), user); err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, user)
+	}
+	return created
+}
+
+func TestMemoryUserRepositoryDuplicateEmail(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryUserRepository()
+	created := createUsers(t, users, "ann", "bob", "cat")
+	if err := users.Delete(ctx, created[2].Id, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		err  error
+	}{
+		{"create with a taken email", func() error {
+			return users.Create(ctx, &models.User{Name: "other", Email: "ann@example.com"})
+		}, ErrDuplicate},
+		{"update to a taken email", func() error {
+			_, err := users.Update(ctx, &models.User{Id: created[1].Id, Name: "bob", Email: "ann@example.com"})
+			return err
+		}, ErrDuplicate},
+		{"create with the email of a deleted user", func() error {
+			return users.Create(ctx, &models.User{Name: "other", Email: "cat@example.com"})
+		}, ErrDuplicate},
+		{"update keeping the own email", func() error {
+			_, err := users.Update(ctx, &models.User{Id: created[1].Id, Name: "robert", Email: "bob@example.com"})
+			return err
+		}, nil},
+		{"update a missing user", func() error {
+			_, err := users.Update(ctx, &models.User{Name: "nobody", Email: "nobody@example.com"})
+			return err
+		}, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMemoryUserRepositorySoftDelete(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryUserRepository()
+	user := createUsers(t, users, "ann")[0]
+
+	if err := users.Delete(ctx, user.Id, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Delete(ctx, user.Id, time.Now().UTC()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting twice: error = %v, want ErrNotFound", err)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		visible bool
+	}{
+		{"hidden by default", ctx, false},
+		{"visible with deleted", WithDeleted(ctx), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := users.FindByID(tt.ctx, user.Id)
+			if (err == nil) != tt.visible {
+				t.Errorf("FindByID error = %v", err)
+			}
+			_, err = users.FindByEmail(tt.ctx, user.Email)
+			if (err == nil) != tt.visible {
+				t.Errorf("FindByEmail error = %v", err)
+			}
+			found, _, err := users.List(tt.ctx, ListOptions{})
+			if err != nil || (len(found) == 1) != tt.visible {
+				t.Errorf("List = %v, %v", found, err)
+			}
+		})
+	}
+
+	if err := users.Restore(ctx, user.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.FindByID(ctx, user.Id); err != nil {
+		t.Errorf("restored user: %v", err)
+	}
+}
+
+func TestMemoryUserRepositoryList(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryUserRepository()
+	createUsers(t, users, "cid", "ann", "bob", "dan")
+
+	tests := []struct {
+		name  string
+		opts  ListOptions
+		names []string
+		total int64
+	}{
+		{"oldest first", ListOptions{}, []string{"cid", "ann", "bob", "dan"}, 0},
+		{"sorted and counted", ListOptions{Sort: bson.D{{Key: "name", Value: 1}}, Count: true}, []string{"ann", "bob", "cid", "dan"}, 4},
+		{"paged", ListOptions{Sort: bson.D{{Key: "name", Value: -1}}, Skip: 1, Limit: 2, Count: true}, []string{"cid", "bob"}, 4},
+		{"filtered", ListOptions{Filter: bson.M{"name": bson.M{"$in": bson.A{"ann", "dan"}}}, Count: true}, []string{"ann", "dan"}, 2},
+		{"skipped past the end", ListOptions{Skip: 10, Count: true}, nil, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, total, err := users.List(ctx, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, user := range found {
+				names = append(names, user.Name)
+			}
+			if !reflect.DeepEqual(names, tt.names) || total != tt.total {
+				t.Errorf("List = %v, %d, want %v, %d", names, total, tt.names, tt.total)
+			}
+		})
+	}
+}
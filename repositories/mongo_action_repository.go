@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoActionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoActionRepository(collection *mongo.Collection) ActionRepository {
+	return &mongoActionRepository{collection: collection}
+}
+
+func (r *mongoActionRepository) Create(ctx context.Context, action *models.Action) error {
+	stampCreated(ctx, &action.AuditFields)
+	result, err := r.collection.InsertOne(ctx, action)
+	if err != nil {
+		return err
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ObjectID")
+	}
+	action.Id = id
+	return nil
+}
+
+func (r *mongoActionRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) (*models.Action, error) {
+	var action models.Action
+	err := r.collection.FindOne(ctx, bson.M{"user": userID}).Decode(&action)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &action, nil
+}
+
+func (r *mongoActionRepository) Replace(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error) {
+	if actions == nil {
+		actions = []string{}
+	}
+	return r.upsert(ctx, userID, bson.M{"$set": bson.M{"actions": actions}})
+}
+
+func (r *mongoActionRepository) Add(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error) {
+	return r.upsert(ctx, userID, bson.M{"$addToSet": bson.M{"actions": bson.M{"$each": actions}}})
+}
+
+func (r *mongoActionRepository) Remove(ctx context.Context, userID primitive.ObjectID, actions []string) (*models.Action, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var action models.Action
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"user": userID}, withUpdateStamp(ctx, bson.M{"$pull": bson.M{"actions": bson.M{"$in": actions}}}), opts).Decode(&action)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &action, nil
+}
+
+func (r *mongoActionRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
+
+func (r *mongoActionRepository) upsert(ctx context.Context, userID primitive.ObjectID, update bson.M) (*models.Action, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+
+	var action models.Action
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"user": userID}, withUpsertStamp(ctx, update), opts).Decode(&action)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &action, nil
+}
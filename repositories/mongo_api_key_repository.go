@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAPIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAPIKeyRepository(collection *mongo.Collection) APIKeyRepository {
+	return &mongoAPIKeyRepository{collection: collection}
+}
+
+func (r *mongoAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	result, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return mapMongoError(err)
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ObjectID")
+	}
+	key.Id = id
+	return nil
+}
+
+func (r *mongoAPIKeyRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cur, err := r.collection.Find(ctx, bson.M{"user": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var keys []models.APIKey
+	if err = cur.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *mongoAPIKeyRepository) FindActive(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.collection.FindOne(ctx, bson.M{"keyHash": keyHash, "revokedAt": bson.M{"$exists": false}}).Decode(&key)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &key, nil
+}
+
+func (r *mongoAPIKeyRepository) Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "user": userID, "revokedAt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revokedAt": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoAPIKeyRepository) RevokeByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"user": userID, "revokedAt": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": at}})
+	return err
+}
+
+func (r *mongoAPIKeyRepository) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": at}})
+	return err
+}
+
+func (r *mongoAPIKeyRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAuditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAuditRepository(collection *mongo.Collection) AuditRepository {
+	return &mongoAuditRepository{collection: collection}
+}
+
+func (r *mongoAuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	result, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ObjectID")
+	}
+	entry.Id = id
+	return nil
+}
+
+func (r *mongoAuditRepository) FindByResource(ctx context.Context, resource string, id primitive.ObjectID) ([]models.AuditEntry, error) {
+	opts := options.Find().SetSort(bson.M{"at": 1})
+	cur, err := r.collection.Find(ctx, bson.M{"resource": resource, "resourceId": id}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var entries []models.AuditEntry
+	if err = cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
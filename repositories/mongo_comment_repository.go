@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// oldestFirstComments is the order threads read in
+var oldestFirstComments = bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+
+type mongoCommentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoCommentRepository(collection *mongo.Collection) CommentRepository {
+	return &mongoCommentRepository{collection: collection}
+}
+
+func (r *mongoCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	stampCreated(ctx, &comment.AuditFields)
+	result, err := r.collection.InsertOne(ctx, comment)
+	if err != nil {
+		return err
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ObjectID")
+	}
+	comment.Id = id
+	return nil
+}
+
+func (r *mongoCommentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	var comment models.Comment
+	err := r.collection.FindOne(ctx, live(ctx, bson.M{"_id": id})).Decode(&comment)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &comment, nil
+}
+
+func (r *mongoCommentRepository) FindRoots(ctx context.Context, postID primitive.ObjectID, parent *models.Comment, skip, limit int64) ([]models.Comment, int64, error) {
+	// comments on the post itself have no parent, which an equality with nil matches
+	filter := bson.M{"post": postID, "parent": nil}
+	if parent != nil {
+		filter["parent"] = parent.Id
+	}
+	filter = live(ctx, filter)
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(oldestFirstComments).SetSkip(skip)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	var comments []models.Comment
+	if err = cur.All(ctx, &comments); err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+func (r *mongoCommentRepository) FindReplies(ctx context.Context, roots []models.Comment, depth int) ([]models.Comment, error) {
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	ids := make(bson.A, len(roots))
+	for i, root := range roots {
+		ids[i] = root.Id
+	}
+
+	// a reply n levels below a root has n more ancestors than the root, so the ancestor
+	// at index level(root)+depth must not exist
+	filter := bson.M{
+		"ancestors": bson.M{"$in": ids},
+		"ancestors." + strconv.Itoa(len(roots[0].Ancestors)+depth): bson.M{"$exists": false},
+	}
+	cur, err := r.collection.Find(ctx, live(ctx, filter), options.Find().SetSort(oldestFirstComments))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var comments []models.Comment
+	if err = cur.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *mongoCommentRepository) Update(ctx context.Context, id primitive.ObjectID, body string) (*models.Comment, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var comment models.Comment
+	err := r.collection.FindOneAndUpdate(ctx, live(ctx, bson.M{"_id": id}), withUpdateStamp(ctx, bson.M{"$set": bson.M{"body": body}}), opts).Decode(&comment)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &comment, nil
+}
+
+func (r *mongoCommentRepository) CountByPosts(ctx context.Context, postIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	counts := map[primitive.ObjectID]int64{}
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	pipeline := []bson.M{
+		{"$match": live(ctx, bson.M{"post": bson.M{"$in": postIDs}})},
+		{"$group": bson.M{"_id": "$post", "count": bson.M{"$sum": 1}}},
+	}
+	cur, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var groups []struct {
+		Post  primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err = cur.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		counts[group.Post] = group.Count
+	}
+	return counts, nil
+}
+
+func (r *mongoCommentRepository) Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	// replies can only be written under live comments, so a deleted comment has no live replies
+	filter := bson.M{
+		"$or":       bson.A{bson.M{"_id": id}, bson.M{"ancestors": id}},
+		"deletedAt": bson.M{"$exists": false},
+	}
+	result, err := r.collection.UpdateMany(ctx, filter, withUpdateStamp(ctx, bson.M{"$set": bson.M{"deletedAt": at}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoCommentRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	filter, err := r.subtrees(ctx, bson.M{"user": userID, "deletedAt": bson.M{"$exists": false}})
+	if err != nil || filter == nil {
+		return err
+	}
+	filter["deletedAt"] = bson.M{"$exists": false}
+	_, err = r.collection.UpdateMany(ctx, filter, withUpdateStamp(ctx, bson.M{"$set": bson.M{"deletedAt": at}}))
+	return err
+}
+
+func (r *mongoCommentRepository) RestoreByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	filter, err := r.subtrees(ctx, bson.M{"user": userID, "deletedAt": at})
+	if err != nil || filter == nil {
+		return err
+	}
+	// replies deleted on their own before the user keep their own deletion time
+	filter["deletedAt"] = at
+	_, err = r.collection.UpdateMany(ctx, filter, withUpdateStamp(ctx, bson.M{"$unset": bson.M{"deletedAt": ""}}))
+	return err
+}
+
+func (r *mongoCommentRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"deletedAt": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *mongoCommentRepository) PurgeByUser(ctx context.Context, userID primitive.ObjectID) error {
+	filter, err := r.subtrees(ctx, bson.M{"user": userID})
+	if err != nil || filter == nil {
+		return err
+	}
+	_, err = r.collection.DeleteMany(ctx, filter)
+	return err
+}
+
+// subtrees filters the comments matching roots and every reply below them, it is nil when
+// nothing matches
+func (r *mongoCommentRepository) subtrees(ctx context.Context, roots bson.M) (bson.M, error) {
+	ids, err := r.collection.Distinct(ctx, "_id", roots)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{"ancestors": bson.M{"$in": ids}},
+	}}, nil
+}
+
+func (r *mongoCommentRepository) PurgeOrphans(ctx context.Context) (int64, error) {
+	// grouping first means each commented post is looked up once, not each comment
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": "$post"}},
+		{"$lookup": bson.M{"from": PostsCollection, "localField": "_id", "foreignField": "_id", "as": "found"}},
+		{"$match": bson.M{"found": bson.M{"$size": 0}}},
+		{"$project": bson.M{"_id": 1}},
+	}
+	cur, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var orphans []struct {
+		Post primitive.ObjectID `bson:"_id"`
+	}
+	if err = cur.All(ctx, &orphans); err != nil {
+		return 0, err
+	}
+	if len(orphans) == 0 {
+		return 0, nil
+	}
+
+	posts := make(bson.A, 0, len(orphans))
+	for _, orphan := range orphans {
+		posts = append(posts, orphan.Post)
+	}
+	result, err := r.collection.DeleteMany(ctx, bson.M{"post": bson.M{"$in": posts}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
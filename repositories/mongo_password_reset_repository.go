@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoPasswordResetRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPasswordResetRepository(collection *mongo.Collection) PasswordResetRepository {
+	return &mongoPasswordResetRepository{collection: collection}
+}
+
+func (r *mongoPasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	result, err := r.collection.InsertOne(ctx, reset)
+	if err != nil {
+		return err
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ObjectID")
+	}
+	reset.Id = id
+	return nil
+}
+
+func (r *mongoPasswordResetRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordReset, error) {
+	// matching and marking in one update makes the token single use even under concurrent requests
+	filter := bson.M{
+		"tokenHash": tokenHash,
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var reset models.PasswordReset
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"usedAt": now}}, opts).Decode(&reset)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &reset, nil
+}
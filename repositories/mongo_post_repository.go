@@ -0,0 +1,299 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newestFirst is the order posts are listed in unless the caller asks for another
+var newestFirst = bson.D{{Key: "created_at", Value: -1}}
+
+type mongoPostRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPostRepository(collection *mongo.Collection) PostRepository {
+	return &mongoPostRepository{collection: collection}
+}
+
+func (r *mongoPostRepository) Create(ctx context.Context, post *models.Post) error {
+	stampCreated(ctx, &post.AuditFields)
+	result, err := r.collection.InsertOne(ctx, post)
+	if err != nil {
+		return err
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ObjectID")
+	}
+	post.Id = id
+	return nil
+}
+
+func (r *mongoPostRepository) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	filter := bson.M{}
+	for field, condition := range opts.Filter {
+		filter[field] = condition
+	}
+	filter = live(ctx, filter)
+	sort := sortOrDefault(opts.Sort, newestFirst)
+
+	result := &ListResult{}
+	if opts.Count {
+		total, err := r.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = total
+	}
+
+	match := filter
+	limit := opts.Limit
+	if opts.Cursor {
+		if opts.After != "" {
+			after, err := decodeCursor(opts.After, sort)
+			if err != nil {
+				return nil, err
+			}
+			match = bson.M{"$and": bson.A{filter, after.after()}}
+		}
+		// one extra document tells whether another page follows
+		limit++
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sort": sort},
+	}
+	if !opts.Cursor {
+		pipeline = append(pipeline, bson.M{"$skip": opts.Skip})
+	}
+	pipeline = append(pipeline, bson.M{"$limit": limit})
+
+	// a cursor is built from the sort keys, so they stay in the documents until it is made
+	projection, added := opts.Projection, []string(nil)
+	if opts.Cursor {
+		projection, added = projectionWithSort(opts.Projection, sort)
+	}
+	if len(projection) > 0 {
+		pipeline = append(pipeline, bson.M{"$project": projection})
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []bson.M
+	if err = cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+
+	if opts.Cursor && int64(len(posts)) > opts.Limit {
+		posts = posts[:opts.Limit]
+		if result.Next, err = encodeCursor(sort, posts[len(posts)-1]); err != nil {
+			return nil, err
+		}
+	}
+	for _, post := range posts {
+		for _, field := range added {
+			delete(post, field)
+		}
+	}
+	result.Items = posts
+	return result, nil
+}
+
+func (r *mongoPostRepository) Search(ctx context.Context, opts SearchOptions) (*ListResult, error) {
+	if !supportedLanguage(opts.Language) {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	text := bson.M{"$search": opts.Text}
+	if opts.Language != "" {
+		text["$language"] = opts.Language
+	}
+	filter := live(ctx, bson.M{"$text": text})
+
+	result := &ListResult{}
+	if opts.Count {
+		total, err := r.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = total
+	}
+
+	// the projection runs afterwards, the highlights need the searched fields
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$addFields": bson.M{"score": bson.M{"$meta": "textScore"}}},
+		{"$sort": bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}},
+		{"$skip": opts.Skip},
+		{"$limit": opts.Limit},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []bson.M
+	if err = cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+
+	terms := parseSearch(opts.Text)
+	for _, post := range posts {
+		score, _ := post["score"].(float64)
+		delete(post, "score")
+		result.Items = append(result.Items, searchHit(post, terms, score, opts.Projection))
+	}
+	return result, nil
+}
+
+func (r *mongoPostRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Post, error) {
+	cur, err := r.collection.Find(ctx, live(ctx, bson.M{"user": userID}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var posts []models.Post
+	if err = cur.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *mongoPostRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	var post models.Post
+	err := r.collection.FindOne(ctx, live(ctx, bson.M{"_id": id})).Decode(&post)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &post, nil
+}
+
+func (r *mongoPostRepository) FindByIDWithAuthor(ctx context.Context, id primitive.ObjectID) (*models.PostWithAuthor, error) {
+	pipeline := []bson.M{
+		{"$match": live(ctx, bson.M{"_id": id})},
+		{"$limit": 1},
+		{"$lookup": bson.M{
+			"from":         UsersCollection,
+			"localField":   "user",
+			"foreignField": "_id",
+			"as":           "author",
+		}},
+		{"$unwind": bson.M{"path": "$author", "preserveNullAndEmptyArrays": true}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []models.PostWithAuthor
+	if err = cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, ErrNotFound
+	}
+	if author := posts[0].Author; author != nil && !visible(ctx, author.DeletedAt) {
+		posts[0].Author = nil
+	}
+	return &posts[0], nil
+}
+
+func (r *mongoPostRepository) Update(ctx context.Context, id primitive.ObjectID, update PostUpdate) (*models.Post, error) {
+	set := bson.M{}
+	if update.Title != nil {
+		set["title"] = *update.Title
+	}
+	if update.Content != nil {
+		set["content"] = *update.Content
+	}
+	if len(set) == 0 {
+		return r.FindByID(ctx, id)
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var post models.Post
+	err := r.collection.FindOneAndUpdate(ctx, live(ctx, bson.M{"_id": id}), withUpdateStamp(ctx, bson.M{"$set": set}), opts).Decode(&post)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &post, nil
+}
+
+func (r *mongoPostRepository) CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, live(ctx, bson.M{"user": userID}))
+}
+
+func (r *mongoPostRepository) Reassign(ctx context.Context, from, to primitive.ObjectID) error {
+	filter := bson.M{"user": from, "deletedAt": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, withUpdateStamp(ctx, bson.M{"$set": bson.M{"user": to}}))
+	return err
+}
+
+func (r *mongoPostRepository) Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, withUpdateStamp(ctx, bson.M{"$set": bson.M{"deletedAt": at}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoPostRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"user": userID, "deletedAt": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, withUpdateStamp(ctx, bson.M{"$set": bson.M{"deletedAt": at}}))
+	return err
+}
+
+func (r *mongoPostRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$exists": true}}
+	result, err := r.collection.UpdateOne(ctx, filter, withUpdateStamp(ctx, bson.M{"$unset": bson.M{"deletedAt": ""}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoPostRepository) RestoreByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"user": userID, "deletedAt": at}
+	_, err := r.collection.UpdateMany(ctx, filter, withUpdateStamp(ctx, bson.M{"$unset": bson.M{"deletedAt": ""}}))
+	return err
+}
+
+func (r *mongoPostRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"deletedAt": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *mongoPostRepository) PurgeByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoRoleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRoleRepository(collection *mongo.Collection) RoleRepository {
+	return &mongoRoleRepository{collection: collection}
+}
+
+func (r *mongoRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"name": role.Name})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+
+	result, err := r.collection.InsertOne(ctx, role)
+	if err != nil {
+		return mapMongoError(err)
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ObjectID")
+	}
+	role.Id = id
+	return nil
+}
+
+func (r *mongoRoleRepository) FindAll(ctx context.Context) ([]models.Role, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoRoleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Role, error) {
+	var role models.Role
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&role)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &role, nil
+}
+
+func (r *mongoRoleRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Role, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *mongoRoleRepository) Update(ctx context.Context, role *models.Role) (*models.Role, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"name": role.Name, "_id": bson.M{"$ne": role.Id}})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrDuplicate
+	}
+
+	update := bson.M{"$set": bson.M{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": role.Permissions,
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.Role
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": role.Id}, update, opts).Decode(&updated)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &updated, nil
+}
+
+func (r *mongoRoleRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoRoleRepository) find(ctx context.Context, filter bson.M) ([]models.Role, error) {
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var roles []models.Role
+	if err = cur.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTokenRepository(collection *mongo.Collection) TokenRepository {
+	return &mongoTokenRepository{collection: collection}
+}
+
+func (r *mongoTokenRepository) Revoke(ctx context.Context, token *models.RevokedToken) error {
+	opts := options.Update().SetUpsert(true)
+	_, err := r.collection.UpdateOne(ctx, bson.M{"jti": token.Jti}, bson.M{"$setOnInsert": bson.M{
+		"jti":       token.Jti,
+		"user":      token.User,
+		"expiresAt": token.ExpiresAt,
+	}}, opts)
+	return err
+}
+
+func (r *mongoTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"jti": jti}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// userRevocationID is the jti of the entry that blocks all of a user's older tokens, it can
+// not clash with a real jti which is plain hex
+func userRevocationID(userID primitive.ObjectID) string {
+	return "user:" + userID.Hex()
+}
+
+func (r *mongoTokenRepository) RevokeUser(ctx context.Context, userID primitive.ObjectID, before, expiresAt time.Time) error {
+	opts := options.Update().SetUpsert(true)
+	_, err := r.collection.UpdateOne(ctx, bson.M{"jti": userRevocationID(userID)}, bson.M{
+		"$set": bson.M{"user": userID},
+		"$max": bson.M{"issuedBefore": before, "expiresAt": expiresAt},
+	}, opts)
+	return err
+}
+
+func (r *mongoTokenRepository) RevokedBefore(ctx context.Context, userID primitive.ObjectID) (time.Time, error) {
+	var entry models.RevokedToken
+	err := r.collection.FindOne(ctx, bson.M{"jti": userRevocationID(userID)}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return entry.IssuedBefore, nil
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/etg-dev/restApi/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoTransactor struct {
+	client *mongo.Client
+
+	mu        sync.Mutex
+	detected  bool
+	supported bool
+}
+
+// NewMongoTransactor runs units of work in a session transaction. Standalone servers
+// can not run transactions, there the writes of a unit are applied one after another.
+func NewMongoTransactor(client *mongo.Client) Transactor {
+	return &mongoTransactor{client: client}
+}
+
+func (t *mongoTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	supported, err := t.supportsTransactions(ctx)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
+// supportsTransactions asks the server once whether it is part of a replica set or a
+// sharded cluster, a failed check is retried on the next call
+func (t *mongoTransactor) supportsTransactions(ctx context.Context) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.detected {
+		return t.supported, nil
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := t.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+
+	t.detected = true
+	t.supported = hello.SetName != "" || hello.Msg == "isdbgrid"
+	if !t.supported {
+		logger.Warnf("mongo is a standalone server, multi collection writes run without a transaction")
+	}
+	return t.supported, nil
+}
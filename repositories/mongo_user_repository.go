@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// oldestFirst lists users in the order they signed up
+var oldestFirst = bson.D{{Key: "_id", Value: 1}}
+
+type mongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoUserRepository(collection *mongo.Collection) UserRepository {
+	return &mongoUserRepository{collection: collection}
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	stampCreated(ctx, &user.AuditFields)
+	result, err := r.collection.InsertOne(ctx, user)
+	if err != nil {
+		return mapMongoError(err)
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ObjectID")
+	}
+	user.Id = id
+	return nil
+}
+
+func (r *mongoUserRepository) List(ctx context.Context, opts ListOptions) ([]models.User, int64, error) {
+	filter := bson.M{}
+	for field, condition := range opts.Filter {
+		filter[field] = condition
+	}
+	filter = live(ctx, filter)
+
+	var total int64
+	if opts.Count {
+		var err error
+		if total, err = r.collection.CountDocuments(ctx, filter); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	findOpts := options.Find().SetSort(sortOrDefault(opts.Sort, oldestFirst)).SetSkip(opts.Skip)
+	if opts.Limit > 0 {
+		findOpts.SetLimit(opts.Limit)
+	}
+	cur, err := r.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	var users []models.User
+	if err = cur.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *mongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, live(ctx, bson.M{"_id": id})).Decode(&user)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &user, nil
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, live(ctx, bson.M{"email": email})).Decode(&user)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &user, nil
+}
+
+func (r *mongoUserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	filter := live(ctx, bson.M{"_id": user.Id})
+	update := withUpdateStamp(ctx, bson.M{"$set": bson.M{"name": user.Name, "email": user.Email}})
+
+	//! to return and update at the same time
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err != nil {
+		return nil, mapMongoError(err)
+	}
+	return &updated, nil
+}
+
+func (r *mongoUserRepository) SetActionID(ctx context.Context, id, actionID primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"actionId": actionID}}
+	if actionID.IsZero() {
+		update = bson.M{"$unset": bson.M{"actionId": ""}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, live(ctx, bson.M{"_id": id}), withUpdateStamp(ctx, update))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) SetPasswordHash(ctx context.Context, id primitive.ObjectID, hash string) error {
+	result, err := r.collection.UpdateOne(ctx, live(ctx, bson.M{"_id": id}), withUpdateStamp(ctx, bson.M{"$set": bson.M{"passwordHash": hash}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, withUpdateStamp(ctx, bson.M{"$set": bson.M{"deletedAt": at}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$exists": true}}
+	result, err := r.collection.UpdateOne(ctx, filter, withUpdateStamp(ctx, bson.M{"$unset": bson.M{"deletedAt": ""}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) DeletedBefore(ctx context.Context, before time.Time) ([]primitive.ObjectID, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cur, err := r.collection.Find(ctx, bson.M{"deletedAt": bson.M{"$lt": before}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cur.Next(ctx) {
+		var user models.User
+		if err = cur.Decode(&user); err != nil {
+			return nil, err
+		}
+		ids = append(ids, user.Id)
+	}
+	return ids, cur.Err()
+}
+
+func (r *mongoUserRepository) Purge(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) AddRole(ctx context.Context, id, roleID primitive.ObjectID) error {
+	result, err := r.collection.UpdateOne(ctx, live(ctx, bson.M{"_id": id}), withUpdateStamp(ctx, bson.M{"$addToSet": bson.M{"roles": roleID}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) RemoveRole(ctx context.Context, id, roleID primitive.ObjectID) error {
+	result, err := r.collection.UpdateOne(ctx, live(ctx, bson.M{"_id": id}), withUpdateStamp(ctx, bson.M{"$pull": bson.M{"roles": roleID}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) RemoveRoleFromAll(ctx context.Context, roleID primitive.ObjectID) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"roles": roleID}, withUpdateStamp(ctx, bson.M{"$pull": bson.M{"roles": roleID}}))
+	return err
+}
+
+// mapMongoError translates driver errors into repository errors
+func mapMongoError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
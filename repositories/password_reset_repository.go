@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, reset *models.PasswordReset) error
+	// Consume marks the unused, unexpired reset with the hash as used and returns it.
+	// Unknown, used and expired tokens return ErrNotFound.
+	Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordReset, error)
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PostRepository interface {
+	// Create inserts the post and sets its Id
+	Create(ctx context.Context, post *models.Post) error
+	// List returns a page of the posts matching opts as raw documents so projections can
+	// drop fields, newest first unless opts.Sort says otherwise
+	List(ctx context.Context, opts ListOptions) (*ListResult, error)
+	// Search ranks the live posts matching a full-text search, best match first. Every item
+	// carries its relevance as score and snippets of the matched fields as highlights.
+	Search(ctx context.Context, opts SearchOptions) (*ListResult, error)
+	FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Post, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error)
+	// FindByIDWithAuthor returns the post joined with the user that wrote it
+	FindByIDWithAuthor(ctx context.Context, id primitive.ObjectID) (*models.PostWithAuthor, error)
+	// Update applies the non nil fields of update and returns the stored post
+	Update(ctx context.Context, id primitive.ObjectID, update PostUpdate) (*models.Post, error)
+	// CountByUser counts the live posts of the user
+	CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	// Reassign hands every live post of one user to another
+	Reassign(ctx context.Context, from, to primitive.ObjectID) error
+	// Delete soft deletes the post at the given time
+	Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// DeleteByUser soft deletes every live post of the user at the given time
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error
+	// Restore undoes Delete, ErrNotFound means there is no deleted post with that id
+	Restore(ctx context.Context, id primitive.ObjectID) error
+	// RestoreByUser restores the posts DeleteByUser deleted at the given time
+	RestoreByUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error
+	// Purge removes posts soft deleted before the given time and returns how many
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// PurgeByUser removes every post of the user for good, deleted or not
+	PurgeByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// PostUpdate lists the post fields to change, nil fields are left untouched
+type PostUpdate struct {
+	Title   *string
+	Content *string
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"github.com/etg-dev/restApi/apperrors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	UsersCollection    = "users"
+	PostsCollection    = "posts"
+	ActionsCollection  = "actions"
+	RolesCollection    = "roles"
+	AuditCollection    = "audit_logs"
+	TokensCollection   = "revoked_tokens"
+	ResetsCollection   = "password_resets"
+	APIKeysCollection  = "api_keys"
+	CommentsCollection = "comments"
+)
+
+// MongoCollections lists every collection the mongo repositories read and write
+var MongoCollections = []string{UsersCollection, PostsCollection, ActionsCollection, RolesCollection, AuditCollection, TokensCollection, ResetsCollection, APIKeysCollection, CommentsCollection}
+
+// ErrNotFound is returned when the requested document does not exist
+var ErrNotFound = apperrors.ErrNotFound
+
+// ErrDuplicate is returned when a write would break a uniqueness rule
+var ErrDuplicate = apperrors.ErrDuplicate
+
+// Repositories groups every repository the handlers depend on
+type Repositories struct {
+	Users    UserRepository
+	Posts    PostRepository
+	Actions  ActionRepository
+	Roles    RoleRepository
+	Audit    AuditRepository
+	Tokens   TokenRepository
+	Resets   PasswordResetRepository
+	APIKeys  APIKeyRepository
+	Comments CommentRepository
+	// Tx groups writes to several repositories into one transaction
+	Tx Transactor
+}
+
+// NewMongoRepositories builds repositories backed by the given database
+func NewMongoRepositories(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Users:    NewMongoUserRepository(db.Collection(UsersCollection)),
+		Posts:    NewMongoPostRepository(db.Collection(PostsCollection)),
+		Actions:  NewMongoActionRepository(db.Collection(ActionsCollection)),
+		Roles:    NewMongoRoleRepository(db.Collection(RolesCollection)),
+		Audit:    NewMongoAuditRepository(db.Collection(AuditCollection)),
+		Tokens:   NewMongoTokenRepository(db.Collection(TokensCollection)),
+		Resets:   NewMongoPasswordResetRepository(db.Collection(ResetsCollection)),
+		APIKeys:  NewMongoAPIKeyRepository(db.Collection(APIKeysCollection)),
+		Comments: NewMongoCommentRepository(db.Collection(CommentsCollection)),
+		Tx:       NewMongoTransactor(db.Client()),
+	}
+}
+
+// NewMemoryRepositories builds repositories that keep everything in process memory
+func NewMemoryRepositories() *Repositories {
+	users := NewMemoryUserRepository()
+	posts := NewMemoryPostRepository(users)
+	return &Repositories{
+		Users:    users,
+		Posts:    posts,
+		Actions:  NewMemoryActionRepository(),
+		Roles:    NewMemoryRoleRepository(),
+		Audit:    NewMemoryAuditRepository(),
+		Tokens:   NewMemoryTokenRepository(),
+		Resets:   NewMemoryPasswordResetRepository(),
+		APIKeys:  NewMemoryAPIKeyRepository(),
+		Comments: NewMemoryCommentRepository(posts),
+		Tx:       NewMemoryTransactor(),
+	}
+}
+
+// ListOptions controls filtering, ordering, paging and projection of list queries
+type ListOptions struct {
+	Skip       int64
+	Limit      int64
+	Projection bson.M
+	// Filter is a mongo query, the memory repositories understand $eq, $ne, $in, $gt, $gte,
+	// $lt, $lte, $regex, $exists, $and and $or
+	Filter bson.M
+	// Sort falls back to the repository's default order when empty, _id always breaks ties
+	Sort bson.D
+	// Count asks for ListResult.Total
+	Count bool
+	// Cursor pages by keyset instead of Skip: After is the Next of the previous page, empty for the first
+	Cursor bool
+	After  string
+}
+
+// ListResult is one page of a list query
+type ListResult struct {
+	Items []bson.M
+	// Total counts every document matching the filter when ListOptions.Count is set
+	Total int64
+	// Next continues a cursor listing, it is empty on the last page
+	Next string
+}
+
+// sortOrDefault returns sort, or fallback when it is empty, with _id appended so paging is stable
+func sortOrDefault(sort, fallback bson.D) bson.D {
+	if len(sort) == 0 {
+		sort = fallback
+	}
+	for _, key := range sort {
+		if key.Key == "_id" {
+			return sort
+		}
+	}
+	return append(append(bson.D(nil), sort...), bson.E{Key: "_id", Value: direction(sort[len(sort)-1].Value)})
+}
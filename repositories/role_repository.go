@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RoleRepository interface {
+	// Create inserts the role and sets its Id, names are unique
+	Create(ctx context.Context, role *models.Role) error
+	FindAll(ctx context.Context) ([]models.Role, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Role, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Role, error)
+	// Update replaces name, description and permissions of the role with role.Id
+	Update(ctx context.Context, role *models.Role) (*models.Role, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
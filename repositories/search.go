@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var ErrUnsupportedLanguage = errors.New("unsupported text search language")
+
+// SearchWeights ranks title matches above content matches. The text index is built with
+// them and the memory store scores its regex matches with them.
+var SearchWeights = bson.D{{Key: "title", Value: 3}, {Key: "content", Value: 1}}
+
+// searchLanguages are the languages mongo text indexes can stem, by name and by code
+var searchLanguages = map[string]bool{
+	"none": true, "danish": true, "da": true, "dutch": true, "nl": true, "english": true, "en": true,
+	"finnish": true, "fi": true, "french": true, "fr": true, "german": true, "de": true,
+	"hungarian": true, "hu": true, "italian": true, "it": true, "norwegian": true, "nb": true,
+	"portuguese": true, "pt": true, "romanian": true, "ro": true, "russian": true, "ru": true,
+	"spanish": true, "es": true, "swedish": true, "sv": true, "turkish": true, "tr": true,
+}
+
+// highlightContext is how many bytes of text a highlight keeps on each side of the first match
+const highlightContext = 60
+
+// SearchOptions describes one page of a full-text search over posts
+type SearchOptions struct {
+	// Text uses the mongo $search syntax: words, "quoted phrases" and -excluded words
+	Text string
+	// Language picks the stemming rules and stop words, empty uses the index default
+	Language   string
+	Skip       int64
+	Limit      int64
+	Projection bson.M
+	// Count asks for ListResult.Total
+	Count bool
+}
+
+func supportedLanguage(language string) bool {
+	return language == "" || searchLanguages[strings.ToLower(language)]
+}
+
+// searchTerms is a parsed search text
+type searchTerms struct {
+	words    []*regexp.Regexp
+	phrases  []*regexp.Regexp
+	excluded []*regexp.Regexp
+	// highlight marks every word and phrase at once
+	highlight *regexp.Regexp
+}
+
+var quotedPhrase = regexp.MustCompile(`"([^"]*)"`)
+
+func parseSearch(text string) searchTerms {
+	var terms searchTerms
+	var marked []string
+	add := func(list *[]*regexp.Regexp, term string, mark bool) {
+		pattern := termPattern(term)
+		*list = append(*list, regexp.MustCompile("(?i)"+pattern))
+		if mark {
+			marked = append(marked, pattern)
+		}
+	}
+
+	for _, match := range quotedPhrase.FindAllStringSubmatch(text, -1) {
+		if phrase := strings.TrimSpace(match[1]); phrase != "" {
+			add(&terms.phrases, phrase, true)
+		}
+	}
+	for _, word := range strings.Fields(quotedPhrase.ReplaceAllString(text, " ")) {
+		if strings.HasPrefix(word, "-") {
+			if word = strings.TrimLeft(word, "-"); word != "" {
+				add(&terms.excluded, word, false)
+			}
+			continue
+		}
+		add(&terms.words, word, true)
+	}
+
+	if len(marked) > 0 {
+		// longer terms first so a phrase wins over the words in it
+		sort.Slice(marked, func(i, j int) bool { return len(marked[i]) > len(marked[j]) })
+		terms.highlight = regexp.MustCompile("(?i)" + strings.Join(marked, "|"))
+	}
+	return terms
+}
+
+// termPattern matches a term at the start of a word, which stands in for stemming: go finds
+// "go" and "goes" but not "cargo"
+func termPattern(term string) string {
+	pattern := regexp.QuoteMeta(term)
+	if wordStart.MatchString(term) {
+		pattern = `\b` + pattern
+	}
+	return pattern
+}
+
+var wordStart = regexp.MustCompile(`^\w`)
+
+// score mimics a text index with a regex per term: every phrase must occur, no excluded word
+// may, and each occurrence counts with the weight of the field it is in
+func (t searchTerms) score(doc bson.M) (float64, bool) {
+	var text strings.Builder
+	for _, field := range SearchWeights {
+		value, _ := doc[field.Key].(string)
+		text.WriteString(value)
+		text.WriteString("\n")
+	}
+	all := text.String()
+
+	for _, excluded := range t.excluded {
+		if excluded.MatchString(all) {
+			return 0, false
+		}
+	}
+	for _, phrase := range t.phrases {
+		if !phrase.MatchString(all) {
+			return 0, false
+		}
+	}
+
+	score := 0.0
+	for _, field := range SearchWeights {
+		value, _ := doc[field.Key].(string)
+		weight := float64(field.Value.(int))
+		for _, term := range append(append([]*regexp.Regexp(nil), t.phrases...), t.words...) {
+			score += weight * float64(len(term.FindAllStringIndex(value, -1)))
+		}
+	}
+	return score, score > 0
+}
+
+// highlights returns a snippet around the first match of every searched field that matched,
+// with the matches wrapped in <em> and everything else html escaped
+func (t searchTerms) highlights(doc bson.M) bson.M {
+	result := bson.M{}
+	if t.highlight == nil {
+		return result
+	}
+	for _, field := range SearchWeights {
+		value, _ := doc[field.Key].(string)
+		if snippet, ok := highlight(value, t.highlight); ok {
+			result[field.Key] = snippet
+		}
+	}
+	return result
+}
+
+func highlight(text string, pattern *regexp.Regexp) (string, bool) {
+	first := pattern.FindStringIndex(text)
+	if first == nil {
+		return "", false
+	}
+
+	start, end := first[0]-highlightContext, first[1]+highlightContext
+	if start < 0 {
+		start = 0
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+	// cut at spaces where there are any so the snippet starts and ends with whole words
+	if i := strings.IndexByte(text[start:first[0]], ' '); start > 0 && i >= 0 {
+		start += i + 1
+	}
+	if i := strings.LastIndexByte(text[first[1]:end], ' '); end < len(text) && i >= 0 {
+		end = first[1] + i
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	window := text[start:end]
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(window, -1) {
+		snippet.WriteString(html.EscapeString(window[last:match[0]]))
+		snippet.WriteString("<em>")
+		snippet.WriteString(html.EscapeString(window[match[0]:match[1]]))
+		snippet.WriteString("</em>")
+		last = match[1]
+	}
+	snippet.WriteString(html.EscapeString(window[last:]))
+	if end < len(text) {
+		snippet.WriteString("…")
+	}
+	return snippet.String(), true
+}
+
+// searchHit finishes a matched document: highlights are taken before the projection may
+// drop the searched fields, then the score and highlights are added
+func searchHit(doc bson.M, terms searchTerms, score float64, projection bson.M) bson.M {
+	highlights := terms.highlights(doc)
+	hit := project(doc, projection)
+	hit["score"] = score
+	hit["highlights"] = highlights
+	return hit
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMemorySearch(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemoryRepositories()
+
+	posts := []models.Post{
+		{Title: "Learning Go", Content: "A first look at the language"},
+		{Title: "Rust or Go", Content: "Go is simple, Go is fast"},
+		{Title: "Cargo cults", Content: "Shipping cargo and other go habits"},
+		{Title: "Gardening", Content: "Nothing about programming at all"},
+		{Title: "Go channels", Content: "Concurrency with go channels and select"},
+	}
+	for i := range posts {
+		if err := repos.Posts.Create(ctx, &posts[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		titles []string
+		scores []float64
+	}{
+		{
+			// a title match weighs 3, a content match 1, ties keep insertion order
+			name:   "title matches rank first",
+			text:   "go",
+			titles: []string{"Rust or Go", "Go channels", "Learning Go", "Cargo cults"},
+			scores: []float64{5, 4, 3, 1},
+		},
+		{
+			name:   "words only match at the start of a word",
+			text:   "cargo",
+			titles: []string{"Cargo cults"},
+			scores: []float64{4},
+		},
+		{
+			name:   "every word adds to the score",
+			text:   "go channels",
+			titles: []string{"Go channels", "Rust or Go", "Learning Go", "Cargo cults"},
+			scores: []float64{8, 5, 3, 1},
+		},
+		{
+			name:   "phrases must occur",
+			text:   `"go channels"`,
+			titles: []string{"Go channels"},
+			scores: []float64{4},
+		},
+		{
+			name:   "excluded words drop the post",
+			text:   "go -rust -cargo",
+			titles: []string{"Go channels", "Learning Go"},
+			scores: []float64{4, 3},
+		},
+		{
+			name: "no match",
+			text: "python",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repos.Posts.Search(ctx, SearchOptions{Text: tt.text, Count: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var titles []string
+			var scores []float64
+			for _, item := range result.Items {
+				titles = append(titles, item["title"].(string))
+				scores = append(scores, item["score"].(float64))
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("titles = %q, want %q", titles, tt.titles)
+			}
+			if !reflect.DeepEqual(scores, tt.scores) {
+				t.Errorf("scores = %v, want %v", scores, tt.scores)
+			}
+			if result.Total != int64(len(tt.titles)) {
+				t.Errorf("total = %d, want %d", result.Total, len(tt.titles))
+			}
+		})
+	}
+}
+
+func TestMemorySearchPageAndProjection(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemoryRepositories()
+	for _, title := range []string{"go one", "go two", "go three"} {
+		if err := repos.Posts.Create(ctx, &models.Post{Title: title, Content: "<b>go</b>"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := repos.Posts.Search(ctx, SearchOptions{Text: "go", Skip: 1, Limit: 1, Count: true, Projection: bson.M{"title": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || len(result.Items) != 1 {
+		t.Fatalf("got %d items of %d, want 1 of 3", len(result.Items), result.Total)
+	}
+
+	hit := result.Items[0]
+	if hit["title"] != "go two" || hit["content"] != nil {
+		t.Errorf("hit = %v, want only the title of the second post", hit)
+	}
+	// highlights are taken before the projection drops the content, which is html escaped
+	want := bson.M{"title": "<em>go</em> two", "content": "&lt;b&gt;<em>go</em>&lt;/b&gt;"}
+	if !reflect.DeepEqual(hit["highlights"], want) {
+		t.Errorf("highlights = %v, want %v", hit["highlights"], want)
+	}
+}
+
+func TestMemorySearchLanguage(t *testing.T) {
+	repos := NewMemoryRepositories()
+
+	tests := []struct {
+		language string
+		err      error
+	}{
+		{"", nil},
+		{"english", nil},
+		{"DE", nil},
+		{"klingon", ErrUnsupportedLanguage},
+	}
+
+	for _, tt := range tests {
+		_, err := repos.Posts.Search(context.Background(), SearchOptions{Text: "go", Language: tt.language})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("language %q: error = %v, want %v", tt.language, err, tt.err)
+		}
+	}
+}
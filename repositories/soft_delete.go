@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type deletedKey struct{}
+
+// WithDeleted returns a context whose queries also return soft deleted users and posts
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedKey{}, true)
+}
+
+func includesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(deletedKey{}).(bool)
+	return include
+}
+
+// live adds the not deleted condition to a mongo filter unless ctx includes deleted documents
+func live(ctx context.Context, filter bson.M) bson.M {
+	if !includesDeleted(ctx) {
+		filter["deletedAt"] = bson.M{"$exists": false}
+	}
+	return filter
+}
+
+// visible is live for documents kept in memory
+func visible(ctx context.Context, deletedAt *time.Time) bool {
+	return deletedAt == nil || includesDeleted(ctx)
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TokenRepository interface {
+	// Revoke blocks the token, revoking it twice is a no-op
+	Revoke(ctx context.Context, token *models.RevokedToken) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// RevokeUser blocks every token of the user issued before the given time, the block is
+	// kept until expiresAt when the last of those tokens would have expired anyway
+	RevokeUser(ctx context.Context, userID primitive.ObjectID, before, expiresAt time.Time) error
+	// RevokedBefore returns the time RevokeUser last blocked the user's tokens before, zero if never
+	RevokedBefore(ctx context.Context, userID primitive.ObjectID) (time.Time, error)
+}
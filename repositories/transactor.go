@@ -0,0 +1,10 @@
+package repositories
+
+import "context"
+
+// Transactor runs writes that span several collections as one unit
+type Transactor interface {
+	// WithinTransaction calls fn with a context that every repository call in the unit must use,
+	// an error from fn rolls back the writes fn made. fn may be called again on transient errors.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
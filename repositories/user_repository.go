@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/etg-dev/restApi/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserRepository interface {
+	// Create inserts the user and sets its Id
+	Create(ctx context.Context, user *models.User) error
+	// List returns a page of the users matching opts.Filter, oldest first unless opts.Sort says
+	// otherwise, and how many match in total when opts.Count is set. Users come back as models
+	// so the json rules hiding the password hash apply, projections and cursors are not supported.
+	List(ctx context.Context, opts ListOptions) ([]models.User, int64, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// Update replaces name and email of the user with user.Id and returns the stored document
+	Update(ctx context.Context, user *models.User) (*models.User, error)
+	// SetActionID links the user to its actions document, a nil id removes the link
+	SetActionID(ctx context.Context, id, actionID primitive.ObjectID) error
+	SetPasswordHash(ctx context.Context, id primitive.ObjectID, hash string) error
+	// Delete soft deletes the user at the given time, it is left out of every query until restored
+	Delete(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// Restore undoes Delete, ErrNotFound means there is no deleted user with that id
+	Restore(ctx context.Context, id primitive.ObjectID) error
+	// DeletedBefore returns the users soft deleted before the given time
+	DeletedBefore(ctx context.Context, before time.Time) ([]primitive.ObjectID, error)
+	// Purge removes the user for good, deleted or not
+	Purge(ctx context.Context, id primitive.ObjectID) error
+	// AddRole assigns the role to the user, assigning it twice is a no-op
+	AddRole(ctx context.Context, id, roleID primitive.ObjectID) error
+	RemoveRole(ctx context.Context, id, roleID primitive.ObjectID) error
+	// RemoveRoleFromAll revokes the role from every user that holds it
+	RemoveRoleFromAll(ctx context.Context, roleID primitive.ObjectID) error
+}
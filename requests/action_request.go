@@ -0,0 +1,19 @@
+package requests
+
+// ActionsRequest is the body of the /api/users/:id/actions writes
+type ActionsRequest struct {
+	Actions []string `json:"actions" binding:"max=16,dive,action"`
+}
+
+// RoleRequest is the body of POST and PUT /api/roles
+type RoleRequest struct {
+	Name        string   `json:"name" binding:"required,notblank,max=64"`
+	Description string   `json:"description" binding:"max=500"`
+	Permissions []string `json:"permissions" binding:"max=16,dive,action"`
+}
+
+// APIKeyRequest is the body of POST /api/users/:id/keys
+type APIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,notblank,max=64"`
+	Scopes []string `json:"scopes" binding:"required,min=1,max=16,dive,action"`
+}
@@ -0,0 +1,12 @@
+package requests
+
+// CreateCommentRequest is the body of POST /api/posts/:postId/comments, a parent id makes it a reply
+type CreateCommentRequest struct {
+	Body   string `json:"body" binding:"required,notblank,max=5000"`
+	Parent string `json:"parent"`
+}
+
+// UpdateCommentRequest is the body of PATCH /api/comments/:id
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required,notblank,max=5000"`
+}
@@ -0,0 +1,30 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// CreatePostRequest is the body of POST /api/posts, PUT uses it too since it replaces the post
+type CreatePostRequest struct {
+	Title   string `json:"title" binding:"required,notblank,max=200"`
+	Content string `json:"content" binding:"required,notblank,max=20000"`
+}
+
+// UpdatePostRequest is the body of PATCH /api/posts/:postId, only the sent fields change
+type UpdatePostRequest struct {
+	Title   *string `json:"title" binding:"omitempty,max=200"`
+	Content *string `json:"content" binding:"omitempty,max=20000"`
+}
+
+// validatePostPatch rejects sent but blank fields, omitempty would let "" through
+func validatePostPatch(sl validator.StructLevel) {
+	patch := sl.Current().Interface().(UpdatePostRequest)
+	if patch.Title != nil && strings.TrimSpace(*patch.Title) == "" {
+		sl.ReportError(patch.Title, "title", "Title", "notblank", "")
+	}
+	if patch.Content != nil && strings.TrimSpace(*patch.Content) == "" {
+		sl.ReportError(patch.Content, "content", "Content", "notblank", "")
+	}
+}
@@ -0,0 +1,56 @@
+package requests
+
+// CreateUserRequest is the body of POST /api/users, the password is optional
+type CreateUserRequest struct {
+	Name     string   `json:"name" binding:"required,notblank,max=100"`
+	Email    string   `json:"email" binding:"required,email,max=254"`
+	Password string   `json:"password" binding:"max=72"`
+	Action   []string `json:"action" binding:"max=16,dive,action"`
+}
+
+// UpdateUserRequest is the body of PUT /api/users/:id
+type UpdateUserRequest struct {
+	Name  string `json:"name" binding:"required,notblank,max=100"`
+	Email string `json:"email" binding:"required,email,max=254"`
+}
+
+// RegisterRequest is the body of POST /api/auth/register
+type RegisterRequest struct {
+	Name     string `json:"name" binding:"required,notblank,max=100"`
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+// LoginRequest is the body of POST /api/auth/login
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+// RefreshRequest is the body of POST /api/auth/refresh
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required,jwt"`
+}
+
+// LogoutRequest is the optional body of POST /api/auth/logout, the refresh token is revoked too when given
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"omitempty,jwt"`
+}
+
+// ChangePasswordRequest is the body of PUT /api/auth/password, the configured password
+// policy is checked on top of the minimum length every policy has
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" binding:"required,max=72"`
+	NewPassword string `json:"newPassword" binding:"required,min=6,max=72"`
+}
+
+// ForgotPasswordRequest is the body of POST /api/auth/password/forgot
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email,max=254"`
+}
+
+// ResetPasswordRequest is the body of POST /api/auth/password/reset, the token is the one mailed by forgot
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required,hexadecimal,len=64"`
+	Password string `json:"password" binding:"required,min=6,max=72"`
+}
@@ -0,0 +1,138 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/etg-dev/restApi/apperrors"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+)
+
+var (
+	setupOnce  sync.Once
+	setupErr   error
+	translator *ut.UniversalTranslator
+)
+
+// Bind decodes the JSON body into dst and validates it. Invalid bodies come back as
+// a validation error with one translated message per field.
+func Bind(c *gin.Context, dst interface{}) error {
+	setupOnce.Do(func() { setupErr = setup() })
+	if setupErr != nil {
+		return apperrors.Internal(setupErr)
+	}
+
+	err := c.ShouldBindJSON(dst)
+	if err == nil {
+		return nil
+	}
+
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return apperrors.Bind(err)
+	}
+
+	trans := findTranslator(c.GetHeader("Accept-Language"))
+	fields := map[string]string{}
+	for _, fe := range invalid {
+		field := fieldPath(fe)
+		if _, seen := fields[field]; !seen {
+			fields[field] = fe.Translate(trans)
+		}
+	}
+	return apperrors.Validation("Request body failed validation", fields).Wrap(err)
+}
+
+// fieldPath is the namespace without the request type, e.g. actions[1]
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+// setup registers the custom validators and translations on gin's validator
+func setup() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("unexpected validator engine %T", binding.Validator.Engine())
+	}
+
+	// report fields by their json names, the same names clients send
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	if err := v.RegisterValidation("action", validateAction); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("notblank", validateNotBlank); err != nil {
+		return err
+	}
+	v.RegisterStructValidation(validatePostPatch, UpdatePostRequest{})
+
+	english := en.New()
+	translator = ut.New(english, english)
+	trans, _ := translator.GetTranslator("en")
+	if err := entranslations.RegisterDefaultTranslations(v, trans); err != nil {
+		return err
+	}
+	if err := registerTranslation(v, trans, "action", "{0} must be one of "+strings.Join(permissions.Known, ", ")); err != nil {
+		return err
+	}
+	return registerTranslation(v, trans, "notblank", "{0} must not be blank")
+}
+
+func registerTranslation(v *validator.Validate, trans ut.Translator, tag string, text string) error {
+	return v.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, text, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			message, err := ut.T(tag, fe.Field())
+			if err != nil {
+				return fe.Error()
+			}
+			return message
+		})
+}
+
+// findTranslator picks the first Accept-Language the translations exist for, english otherwise
+func findTranslator(acceptLanguage string) ut.Translator {
+	var locales []string
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		locale, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if locale != "" {
+			locales = append(locales, strings.ReplaceAll(locale, "-", "_"))
+		}
+	}
+	trans, _ := translator.FindTranslator(locales...)
+	return trans
+}
+
+// validateAction accepts the action names of the permission registry
+func validateAction(fl validator.FieldLevel) bool {
+	return permissions.IsKnown(fl.Field().String())
+}
+
+// validateNotBlank rejects strings made only of whitespace, required lets those through
+func validateNotBlank(fl validator.FieldLevel) bool {
+	return strings.TrimSpace(fl.Field().String()) != ""
+}
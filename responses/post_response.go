@@ -1,7 +1,3 @@
 package responses
 
-type PostResponse struct {
-	Status  int                    `json:"status"`
-	Message string                 `json:"message"`
-	Data    map[string]interface{} `json:"data"`
-}
+type PostResponse = Response
@@ -0,0 +1,20 @@
+package responses
+
+// Response is the envelope every endpoint answers with, Status always equals the HTTP status
+type Response struct {
+	Status  int                    `json:"status"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data"`
+	// Meta describes the page a list endpoint returned
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// Meta locates a page within the full result. Offset pages fill Page, Total and
+// TotalPages, cursor pages fill Next instead.
+type Meta struct {
+	Page       int64  `json:"page,omitempty"`
+	PageSize   int64  `json:"pageSize"`
+	Total      *int64 `json:"total,omitempty"`
+	TotalPages *int64 `json:"totalPages,omitempty"`
+	Next       string `json:"next,omitempty"`
+}
@@ -0,0 +1,3 @@
+package responses
+
+type RoleResponse = Response
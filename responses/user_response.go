@@ -1,7 +1,3 @@
 package responses
 
-type UserResponse struct {
-	Status  int                    `json:"status"`
-	Message string                 `json:"message"`
-	Data    map[string]interface{} `json:"data"`
-}
+type UserResponse = Response
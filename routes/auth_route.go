@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/controllers"
+	"github.com/etg-dev/restApi/mailer"
+	"github.com/etg-dev/restApi/middleware"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+func AuthRoute(router *gin.Engine, repos *repositories.Repositories, cfg *configs.Config, tokens *auth.TokenService, mail mailer.Mailer) {
+	authGroup := router.Group("/api/auth")
+	{
+		authGroup.POST("/login", controllers.Login(repos.Users, tokens))
+		authGroup.POST("/refresh", controllers.Refresh(repos.Users, tokens))
+		authGroup.POST("/logout", middleware.Authenticate(tokens, repos.APIKeys), controllers.Logout(tokens))
+		authGroup.POST("/register", controllers.Register(repos.Users, repos.Actions, repos.Tx, cfg.Auth))
+		authGroup.POST("/password/forgot", controllers.ForgotPassword(repos.Users, repos.Resets, mail, cfg))
+		authGroup.POST("/password/reset", controllers.ResetPassword(repos.Users, repos.Resets, tokens, repos.APIKeys, cfg.Auth.Password))
+		authGroup.PUT("/password", middleware.Authenticate(tokens, repos.APIKeys), middleware.ValidateUserID(repos.Users), controllers.ChangePassword(repos.Users, tokens, repos.APIKeys, cfg.Auth.Password))
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/configs"
+	"github.com/etg-dev/restApi/controllers"
+	"github.com/etg-dev/restApi/middleware"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+func CommentRoute(router *gin.Engine, repos *repositories.Repositories, cfg *configs.Config, tokens *auth.TokenService) {
+	resolver := permissions.NewResolver(repos)
+
+	// the author is always the authenticated user, never an id taken from the body
+	postComments := router.Group("/api/posts/:postId/comments", middleware.Authenticate(tokens, repos.APIKeys), middleware.ValidateUserID(repos.Users))
+	{
+		postComments.GET("/", middleware.ValidateAction(resolver, permissions.Read), middleware.Paginate(cfg.Pagination), controllers.GetComments(repos.Posts, repos.Comments))
+		postComments.POST("/", middleware.ValidateAction(resolver, permissions.Create), controllers.CreateComment(repos.Posts, repos.Comments))
+	}
+
+	commentGroup := router.Group("/api/comments", middleware.Authenticate(tokens, repos.APIKeys), middleware.ValidateUserID(repos.Users))
+	{
+		commentGroup.PATCH("/:id", middleware.ValidateAction(resolver, permissions.Update), middleware.ValidateCommentOwner(repos.Comments, resolver), controllers.UpdateComment(repos.Comments))
+		commentGroup.DELETE("/:id", middleware.ValidateAction(resolver, permissions.Delete), middleware.ValidateCommentOwner(repos.Comments, resolver), controllers.DeleteComment(repos.Comments))
+	}
+}
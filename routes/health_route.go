@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/etg-dev/restApi/controllers"
+	"github.com/etg-dev/restApi/health"
+	"github.com/gin-gonic/gin"
+)
+
+func HealthRoute(router *gin.Engine, checker *health.Checker) {
+	router.GET("/healthz", controllers.Liveness())
+	router.GET("/readyz", controllers.Readiness(checker))
+}
@@ -1,17 +1,34 @@
 package routes
 
 import (
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/configs"
 	"github.com/etg-dev/restApi/controllers"
 	"github.com/etg-dev/restApi/middleware"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/query"
+	"github.com/etg-dev/restApi/repositories"
 	"github.com/gin-gonic/gin"
 )
 
-func PostRoute(router *gin.Engine) {
+func PostRoute(router *gin.Engine, repos *repositories.Repositories, cfg *configs.Config, tokens *auth.TokenService) {
+	resolver := permissions.NewResolver(repos)
+
 	postGroup := router.Group("/api/posts")
 	{
-		postGroup.GET("/user/:userId", controllers.GetUsersPosts())
-		postGroup.GET("/:userId", middleware.ValidateUserID(), middleware.ValidateAction("Read"), middleware.Paginate(), controllers.GetPosts())
-		postGroup.POST("/:userId", controllers.CreatePost())
+		postGroup.GET("/user/:userId", middleware.OptionalAuthenticate(tokens, repos.APIKeys), middleware.Paginate(cfg.Pagination), middleware.IncludeDeleted(resolver), middleware.SelectFields(query.PostFields), controllers.GetUsersPosts(repos.Posts, repos.Comments))
+		postGroup.GET("/item/:postId", middleware.OptionalAuthenticate(tokens, repos.APIKeys), middleware.IncludeDeleted(resolver), middleware.SelectFields(query.PostWithAuthorFields), controllers.GetPost(repos.Posts))
 	}
 
+	// the author is always the authenticated user, never an id taken from the url
+	authGroup := postGroup.Group("", middleware.Authenticate(tokens, repos.APIKeys), middleware.ValidateUserID(repos.Users))
+	{
+		authGroup.GET("/", middleware.ValidateAction(resolver, permissions.Read), middleware.Paginate(cfg.Pagination), middleware.IncludeDeleted(resolver), middleware.ListQuery(query.Posts), middleware.SelectFields(query.PostFields), controllers.GetPosts(repos.Posts, repos.Comments))
+		authGroup.GET("/search", middleware.ValidateAction(resolver, permissions.Read), middleware.Paginate(cfg.Pagination), middleware.SelectFields(query.PostFields), controllers.SearchPosts(repos.Posts, repos.Comments))
+		authGroup.POST("/", middleware.ValidateAction(resolver, permissions.Create), controllers.CreatePost(repos.Posts))
+		authGroup.PUT("/:postId", middleware.ValidateAction(resolver, permissions.Update), middleware.ValidatePostOwner(repos.Posts, resolver), controllers.UpdatePost(repos.Posts))
+		authGroup.PATCH("/:postId", middleware.ValidateAction(resolver, permissions.Update), middleware.ValidatePostOwner(repos.Posts, resolver), controllers.UpdatePost(repos.Posts))
+		authGroup.DELETE("/:postId", middleware.ValidateAction(resolver, permissions.Delete), middleware.ValidatePostOwner(repos.Posts, resolver), controllers.DeletePost(repos.Posts))
+		authGroup.POST("/:postId/restore", middleware.ValidateAction(resolver, permissions.Admin), controllers.RestorePost(repos.Posts))
+	}
 }
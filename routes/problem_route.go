@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/etg-dev/restApi/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func ProblemRoute(router *gin.Engine) {
+	router.GET("/problems/:type", controllers.GetProblemType())
+}
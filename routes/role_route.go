@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/controllers"
+	"github.com/etg-dev/restApi/middleware"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+func RoleRoute(router *gin.Engine, repos *repositories.Repositories, tokens *auth.TokenService) {
+	resolver := permissions.NewResolver(repos)
+
+	roleGroup := router.Group("/api/roles", middleware.Authenticate(tokens, repos.APIKeys), middleware.ValidateUserID(repos.Users))
+	{
+		roleGroup.GET("/", controllers.GetRoles(repos.Roles))
+		roleGroup.GET("/:id", controllers.GetRole(repos.Roles))
+	}
+
+	adminGroup := roleGroup.Group("", middleware.ValidateAction(resolver, permissions.Admin))
+	{
+		adminGroup.POST("/", controllers.CreateRole(repos.Roles))
+		adminGroup.PUT("/:id", controllers.UpdateRole(repos.Roles))
+		adminGroup.DELETE("/:id", controllers.DeleteRole(repos.Roles, repos.Users, repos.Tx))
+	}
+}
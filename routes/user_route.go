@@ -1,17 +1,49 @@
 package routes
 
 import (
+	"github.com/etg-dev/restApi/audit"
+	"github.com/etg-dev/restApi/auth"
+	"github.com/etg-dev/restApi/configs"
 	"github.com/etg-dev/restApi/controllers"
+	"github.com/etg-dev/restApi/middleware"
+	"github.com/etg-dev/restApi/permissions"
+	"github.com/etg-dev/restApi/query"
+	"github.com/etg-dev/restApi/repositories"
 	"github.com/gin-gonic/gin"
 )
 
-func UserRoute(router *gin.Engine) {
+func UserRoute(router *gin.Engine, repos *repositories.Repositories, cfg *configs.Config, tokens *auth.TokenService) {
+	recorder := audit.NewRecorder(repos.Audit)
+	resolver := permissions.NewResolver(repos)
+
 	userGroups := router.Group("/api/users")
 	{
-		userGroups.GET("/:id", controllers.GetUser())
-		userGroups.PUT("/:id", controllers.UpdateUser())
-		userGroups.DELETE("/:id", controllers.DeleteUser())
-		userGroups.GET("/", controllers.GetUsers())
-		userGroups.POST("/", controllers.CreateUser())
+		// anyone may read users, admins who authenticate can also ask for deleted ones
+		userGroups.GET("/:id", middleware.OptionalAuthenticate(tokens, repos.APIKeys), middleware.IncludeDeleted(resolver), middleware.SelectFields(query.UserFields), controllers.GetUser(repos.Users))
+		userGroups.GET("/", middleware.OptionalAuthenticate(tokens, repos.APIKeys), middleware.Paginate(cfg.Pagination), middleware.IncludeDeleted(resolver), middleware.ListQuery(query.Users), middleware.SelectFields(query.UserFields), controllers.GetUsers(repos.Users))
+	}
+
+	authGroup := userGroups.Group("", middleware.Authenticate(tokens, repos.APIKeys), middleware.ValidateUserID(repos.Users))
+
+	selfGroup := authGroup.Group("", middleware.ValidateSelfOrAction(resolver, permissions.Admin))
+	{
+		selfGroup.PUT("/:id", controllers.UpdateUser(repos.Users))
+		selfGroup.DELETE("/:id", controllers.DeleteUser(repos.Users, repos.Posts, repos.Comments, repos.Tx, cfg.Cascade))
+		selfGroup.GET("/:id/actions", controllers.GetUserActions(repos.Users, repos.Actions))
+		selfGroup.GET("/:id/keys", controllers.GetAPIKeys(repos.Users, repos.APIKeys))
+		selfGroup.POST("/:id/keys", controllers.CreateAPIKey(repos.Users, repos.APIKeys, recorder))
+		selfGroup.DELETE("/:id/keys/:keyId", controllers.RevokeAPIKey(repos.Users, repos.APIKeys, recorder))
+	}
+
+	adminGroup := authGroup.Group("", middleware.ValidateAction(resolver, permissions.Admin))
+	{
+		// admins create users with any actions, everybody else signs up through /api/auth/register
+		adminGroup.POST("/", controllers.CreateUser(repos.Users, repos.Actions, repos.Tx, cfg.Auth.Password))
+		adminGroup.PUT("/:id/actions", controllers.ReplaceUserActions(repos.Users, repos.Actions, recorder))
+		adminGroup.PATCH("/:id/actions", controllers.AddUserActions(repos.Users, repos.Actions, recorder))
+		adminGroup.DELETE("/:id/actions", controllers.RemoveUserActions(repos.Users, repos.Actions, repos.Tx, recorder))
+		adminGroup.POST("/:id/restore", controllers.RestoreUser(repos.Users, repos.Posts, repos.Comments, repos.Tx))
+		adminGroup.POST("/:id/roles/:roleId", controllers.AssignRole(repos.Users, repos.Roles))
+		adminGroup.DELETE("/:id/roles/:roleId", controllers.RevokeRole(repos.Users))
 	}
 }
@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/etg-dev/restApi/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// index conflict codes of the server, the index exists with other keys or options
+const (
+	indexOptionsConflict  = 85
+	indexKeySpecsConflict = 86
+)
+
+// EnsureIndexes creates the declared indexes that do not exist yet. Creating an
+// existing index with the same definition is a no-op, so this runs on every start.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, collection := range collections() {
+		models := Indexes[collection]
+		names, err := db.Collection(collection).Indexes().CreateMany(ctx, models)
+		if err != nil {
+			return indexError(collection, err)
+		}
+		logger.Debugf("indexes on %s: %v", collection, names)
+	}
+	return nil
+}
+
+// MissingIndexes lists the declared indexes as collection.name that are not on the server
+func MissingIndexes(ctx context.Context, db *mongo.Database) ([]string, error) {
+	var missing []string
+	for _, collection := range collections() {
+		existing, err := indexNames(ctx, db.Collection(collection))
+		if err != nil {
+			return nil, err
+		}
+		for _, model := range Indexes[collection] {
+			if name := *model.Options.Name; !existing[name] {
+				missing = append(missing, collection+"."+name)
+			}
+		}
+	}
+	return missing, nil
+}
+
+func indexNames(ctx context.Context, collection *mongo.Collection) (map[string]bool, error) {
+	cur, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var specs []bson.M
+	if err = cur.All(ctx, &specs); err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, spec := range specs {
+		if name, ok := spec["name"].(string); ok {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// collections returns the collections with indexes in a stable order
+func collections() []string {
+	var names []string
+	for name := range Indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func indexError(collection string, err error) error {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == indexOptionsConflict || cmdErr.Code == indexKeySpecsConflict) {
+		return fmt.Errorf("index on %s differs from its declaration, drop it or add a migration: %w", collection, err)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("unique index on %s can not be built over duplicate documents, clean them up first: %w", collection, err)
+	}
+	return fmt.Errorf("creating indexes on %s: %w", collection, err)
+}
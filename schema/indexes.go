@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"github.com/etg-dev/restApi/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Indexes declares the indexes of every collection. Each index is named so that
+// changing its keys or options shows up as a conflict instead of a second index.
+var Indexes = map[string][]mongo.IndexModel{
+	repositories.UsersCollection: {
+		// older users may have no email, those stay out of the unique index. Soft deleted users
+		// stay in it, a partial filter can not select on a missing deletedAt, so their email is
+		// only free again after the purge and a restore never collides with a newer account.
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email_unique").SetUnique(true).SetPartialFilterExpression(bson.M{"email": bson.M{"$exists": true}}),
+		},
+		deletedAtIndex,
+	},
+	repositories.PostsCollection: {
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("user_created_at")},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}, Options: options.Index().SetName("created_at")},
+		{Keys: bson.D{{Key: "updated_at", Value: -1}}, Options: options.Index().SetName("updated_at")},
+		{
+			Keys:    bson.D{{Key: "title", Value: "text"}, {Key: "content", Value: "text"}},
+			Options: options.Index().SetName("text_search").SetWeights(repositories.SearchWeights),
+		},
+		deletedAtIndex,
+	},
+	repositories.CommentsCollection: {
+		{Keys: bson.D{{Key: "post", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetName("post_created_at")},
+		// a page of thread roots is the comments of one parent, oldest first
+		{Keys: bson.D{{Key: "post", Value: 1}, {Key: "parent", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetName("post_parent_created_at")},
+		{Keys: bson.D{{Key: "ancestors", Value: 1}}, Options: options.Index().SetName("ancestors")},
+		{Keys: bson.D{{Key: "user", Value: 1}}, Options: options.Index().SetName("user")},
+		deletedAtIndex,
+	},
+	repositories.ActionsCollection: {
+		{Keys: bson.D{{Key: "user", Value: 1}}, Options: options.Index().SetName("user")},
+	},
+	repositories.RolesCollection: {
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetName("name_unique").SetUnique(true)},
+	},
+	repositories.AuditCollection: {
+		{Keys: bson.D{{Key: "resource", Value: 1}, {Key: "resourceId", Value: 1}, {Key: "at", Value: -1}}, Options: options.Index().SetName("resource_at")},
+	},
+	repositories.TokensCollection: {
+		{Keys: bson.D{{Key: "jti", Value: 1}}, Options: options.Index().SetName("jti_unique").SetUnique(true)},
+		// revoked tokens only matter until they would have expired anyway
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0)},
+	},
+	repositories.ResetsCollection: {
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetName("token_hash_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0)},
+	},
+	repositories.APIKeysCollection: {
+		{Keys: bson.D{{Key: "keyHash", Value: 1}}, Options: options.Index().SetName("key_hash_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "user", Value: 1}}, Options: options.Index().SetName("user")},
+	},
+}
+
+// deletedAtIndex serves the purge job, only soft deleted documents are in it
+var deletedAtIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "deletedAt", Value: 1}},
+	Options: options.Index().SetName("deleted_at").SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+}
@@ -8,18 +8,16 @@ import (
 	"path"
 	"time"
 
-	"github.com/etg-dev/restApi/configs"
 	"github.com/etg-dev/restApi/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var userCollection *mongo.Collection = configs.GetCollection(configs.DB, "users")
-var postCollection *mongo.Collection = configs.GetCollection(configs.DB, "posts")
+// InjectDB inserts the seed data into the given database
+func InjectDB(db *mongo.Database) error {
+	postCollection := db.Collection("posts")
 
-// SeedUsers inserts user data into the given collection
-func InjectDB() error {
 	var users []models.User
 	var posts []models.Post
 
@@ -67,8 +65,10 @@ func InjectDB() error {
 	}
 
 	var postDocs []interface{}
+	now := time.Now().UTC()
 	for _, post := range posts {
 		post.User = primitive.NewObjectID()
+		post.CreatedAt, post.UpdatedAt = now, now
 		postDocs = append(postDocs, post)
 	}
 
@@ -81,8 +81,11 @@ func InjectDB() error {
 	return nil
 }
 
-// DeleteUsers deletes all data from the given collection
-func DrainDB() error {
+// DrainDB deletes all seeded data from the given database
+func DrainDB(db *mongo.Database) error {
+	userCollection := db.Collection("users")
+	postCollection := db.Collection("posts")
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 